@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"net/http"
+
+	"llm-mock-server/pkg/provider/chat"
+	"llm-mock-server/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PathPrefix is the path prefix of the admin API. Requests below it never reach the provider mocks.
+const PathPrefix = "/__admin"
+
+// SetupRoutes registers the admin API that lets tests control the mock server at runtime. The
+// responses are the ones the Responses API of the server stores.
+func SetupRoutes(server *gin.Engine, responses *chat.ResponseStore) {
+	group := server.Group(PathPrefix)
+
+	group.POST("/stubs", createStub)
+	group.GET("/stubs", listStubs)
+	group.DELETE("/stubs", resetStubs)
+	group.GET("/stubs/:id", getStub)
+	group.DELETE("/stubs/:id", deleteStub)
+
+	group.GET("/requests", listRequests)
+	group.DELETE("/requests", resetRequests)
+	group.GET("/requests/:id", getRequest)
+
+	group.POST("/verify", verifyRequests)
+
+	group.DELETE("/ratelimits", resetRateLimits)
+
+	group.DELETE("/responses", func(ctx *gin.Context) {
+		responses.Reset()
+		ctx.Status(http.StatusNoContent)
+	})
+
+	group.POST("/keys", setKey)
+	group.GET("/keys", listKeys)
+	group.DELETE("/keys", resetKeys)
+	group.GET("/keys/:key", getKey)
+	group.DELETE("/keys/:key", deleteKey)
+}
+
+// resetRateLimits gives every API key its full rate limit budgets back.
+func resetRateLimits(ctx *gin.Context) {
+	ratelimit.DefaultLimiter.Reset()
+	ctx.Status(http.StatusNoContent)
+}
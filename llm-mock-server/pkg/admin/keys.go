@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+
+	"llm-mock-server/pkg/apikey"
+	"llm-mock-server/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setKey registers an API key, or changes the state of a registered one.
+func setKey(ctx *gin.Context) {
+	var key config.APIKey
+	if err := ctx.ShouldBindJSON(&key); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := apikey.DefaultRegistry.Set(&key); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, key)
+}
+
+func listKeys(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"keys": apikey.DefaultRegistry.List()})
+}
+
+func getKey(ctx *gin.Context) {
+	key := apikey.DefaultRegistry.Get(ctx.Param("key"))
+	if key == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
+
+func deleteKey(ctx *gin.Context) {
+	if !apikey.DefaultRegistry.Delete(ctx.Param("key")) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// resetKeys restores the keys of the config file.
+func resetKeys(ctx *gin.Context) {
+	apikey.DefaultRegistry.Reset()
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"llm-mock-server/pkg/journal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listRequests returns the journal entries, oldest first. Supported query parameters:
+// provider, path, header (repeatable, "Name:Value") and limit (keep only the newest N entries).
+func listRequests(ctx *gin.Context) {
+	filter := journal.Filter{
+		Provider: ctx.Query("provider"),
+		Path:     ctx.Query("path"),
+	}
+	for _, header := range ctx.QueryArray("header") {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "header filter must be in the form Name:Value"})
+			return
+		}
+		if filter.Headers == nil {
+			filter.Headers = make(map[string]string)
+		}
+		filter.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	entries := journal.DefaultJournal.Find(filter)
+	var query struct {
+		Limit int `form:"limit"`
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[len(entries)-query.Limit:]
+	}
+	ctx.JSON(http.StatusOK, gin.H{"requests": entries, "total": len(entries)})
+}
+
+func getRequest(ctx *gin.Context) {
+	entry := journal.DefaultJournal.Get(ctx.Param("id"))
+	if entry == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, entry)
+}
+
+func resetRequests(ctx *gin.Context) {
+	journal.DefaultJournal.Reset()
+	ctx.Status(http.StatusNoContent)
+}
+
+// verifyRequests checks that the number of journal entries matching a pattern meets the expected
+// count. It answers 200 when the verification passes and 417 with the closest near misses otherwise.
+func verifyRequests(ctx *gin.Context) {
+	var verification journal.Verification
+	if err := ctx.ShouldBindJSON(&verification); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := verification.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result := journal.DefaultJournal.Verify(&verification)
+	status := http.StatusOK
+	if !result.Passed {
+		status = http.StatusExpectationFailed
+	}
+	ctx.JSON(status, result)
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+
+	"llm-mock-server/pkg/stub"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createStub(ctx *gin.Context) {
+	var s stub.Stub
+	if err := ctx.ShouldBindJSON(&s); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := stub.DefaultStore.Add(&s); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, s)
+}
+
+func listStubs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"stubs": stub.DefaultStore.List()})
+}
+
+func getStub(ctx *gin.Context) {
+	s := stub.DefaultStore.Get(ctx.Param("id"))
+	if s == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "stub not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, s)
+}
+
+func deleteStub(ctx *gin.Context) {
+	if !stub.DefaultStore.Delete(ctx.Param("id")) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "stub not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func resetStubs(ctx *gin.Context) {
+	stub.DefaultStore.Reset()
+	ctx.Status(http.StatusNoContent)
+}
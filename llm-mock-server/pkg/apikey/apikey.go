@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"sort"
+	"sync"
+
+	"llm-mock-server/pkg/config"
+)
+
+// ModelNotAllowed is the rejection of a valid key used with a model outside its allowed list.
+const ModelNotAllowed = "modelNotAllowed"
+
+// Registry keeps the registered API keys. It starts from the keys of the config and can be changed
+// at runtime through the admin API, e.g. to expire a key in the middle of a test.
+type Registry struct {
+	mutex  sync.RWMutex
+	strict bool
+	keys   map[string]*config.APIKey
+	// seed is the config the registry was loaded from, restored by Reset.
+	seed *config.APIKeyConfig
+}
+
+// DefaultRegistry is the registry shared by the admin API and the chat handlers.
+var DefaultRegistry = &Registry{keys: make(map[string]*config.APIKey)}
+
+// Load replaces the registered keys with the ones of the config.
+func (r *Registry) Load(cfg *config.APIKeyConfig) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.seed = cfg
+	r.load()
+}
+
+func (r *Registry) load() {
+	r.keys = make(map[string]*config.APIKey)
+	r.strict = false
+	if r.seed == nil {
+		return
+	}
+	r.strict = r.seed.Strict
+	for i := range r.seed.Keys {
+		key := r.seed.Keys[i]
+		r.keys[key.Key] = &key
+	}
+}
+
+// Set validates the key and registers it, replacing the key with the same value.
+func (r *Registry) Set(key *config.APIKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys[key.Key] = key
+	return nil
+}
+
+// List returns the registered keys sorted by value.
+func (r *Registry) List() []*config.APIKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	keys := make([]*config.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+func (r *Registry) Get(key string) *config.APIKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.keys[key]
+}
+
+func (r *Registry) Delete(key string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.keys[key]; !ok {
+		return false
+	}
+	delete(r.keys, key)
+	return true
+}
+
+// Reset restores the keys of the config.
+func (r *Registry) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.load()
+}
+
+// Check returns why a request authenticated with the key for the model is rejected: the state of
+// the key, or ModelNotAllowed. It returns an empty string if the request is accepted. Keys that
+// are not registered are accepted unless the registry is strict.
+func (r *Registry) Check(key, model string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	registered, ok := r.keys[key]
+	if !ok {
+		if r.strict {
+			return config.KeyInvalid
+		}
+		return ""
+	}
+	if registered.State != config.KeyValid {
+		return registered.State
+	}
+	if len(registered.Models) == 0 {
+		return ""
+	}
+	for _, allowed := range registered.Models {
+		if allowed == model {
+			return ""
+		}
+	}
+	return ModelNotAllowed
+}
@@ -0,0 +1,57 @@
+package apikey
+
+import (
+	"testing"
+
+	"llm-mock-server/pkg/config"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	r := &Registry{}
+	r.Load(&config.APIKeyConfig{Keys: []config.APIKey{
+		{Key: "sk-valid", State: config.KeyValid},
+		{Key: "sk-expired", State: config.KeyExpired},
+		{Key: "sk-empty", State: config.KeyQuotaExhausted},
+		{Key: "sk-mini", State: config.KeyValid, Models: []string{"gpt-4o-mini"}},
+	}})
+
+	tests := []struct {
+		key, model string
+		expected   string
+	}{
+		{"sk-valid", "gpt-4o", ""},
+		{"sk-unknown", "gpt-4o", ""},
+		{"sk-expired", "gpt-4o", config.KeyExpired},
+		{"sk-empty", "gpt-4o", config.KeyQuotaExhausted},
+		{"sk-mini", "gpt-4o-mini", ""},
+		{"sk-mini", "gpt-4o", ModelNotAllowed},
+	}
+	for _, test := range tests {
+		if got := r.Check(test.key, test.model); got != test.expected {
+			t.Errorf("Check(%q, %q) = %q, expected %q", test.key, test.model, got, test.expected)
+		}
+	}
+}
+
+func TestRegistryStrictAndReset(t *testing.T) {
+	r := &Registry{}
+	r.Load(&config.APIKeyConfig{Strict: true, Keys: []config.APIKey{{Key: "sk-valid"}}})
+
+	if got := r.Check("sk-unknown", "m"); got != config.KeyInvalid {
+		t.Errorf("Expected unknown keys to be invalid in strict mode, got %q", got)
+	}
+	if err := r.Set(&config.APIKey{Key: "sk-valid", State: config.KeyExpired}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Check("sk-valid", "m"); got != config.KeyExpired {
+		t.Errorf("Expected the updated key to be expired, got %q", got)
+	}
+	if err := r.Set(&config.APIKey{Key: "sk-bad", State: "revoked"}); err == nil {
+		t.Error("Expected an unknown state to be rejected")
+	}
+
+	r.Reset()
+	if got := r.Check("sk-valid", "m"); got != "" {
+		t.Errorf("Expected the config keys to be restored, got %q", got)
+	}
+}
@@ -0,0 +1,179 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/log"
+)
+
+// redactedHeaders are the credential headers never written into a cassette file.
+var redactedHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "Cookie"}
+
+// hopHeaders are connection-level response headers that do not survive the replay.
+var hopHeaders = []string{"Connection", "Content-Length", "Content-Encoding", "Keep-Alive", "Transfer-Encoding"}
+
+var fileNameReplacer = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// Cassette is a recorded request/response pair. The response body is kept as the sequence of
+// chunks read from the upstream, each with its offset from the moment the request was forwarded,
+// so that replayed responses keep the original latency and streaming timing.
+type Cassette struct {
+	RecordedAt time.Time `json:"recordedAt"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	Host    string      `json:"host"`
+	Path    string      `json:"path"`
+	Query   string      `json:"query,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Chunks  []Chunk     `json:"chunks"`
+}
+
+// Chunk is a piece of the response body. Data that is not valid UTF-8 (the Bedrock binary event
+// stream for instance) is stored base64-encoded.
+type Chunk struct {
+	Offset config.Duration `json:"offset"`
+	Data   string          `json:"data"`
+	Base64 bool            `json:"base64,omitempty"`
+}
+
+func newChunk(offset time.Duration, data []byte) Chunk {
+	if utf8.Valid(data) {
+		return Chunk{Offset: config.Duration(offset), Data: string(data)}
+	}
+	return Chunk{Offset: config.Duration(offset), Data: base64.StdEncoding.EncodeToString(data), Base64: true}
+}
+
+func (c Chunk) Bytes() []byte {
+	if !c.Base64 {
+		return []byte(c.Data)
+	}
+	data, err := base64.StdEncoding.DecodeString(c.Data)
+	if err != nil {
+		log.Errorf("invalid base64 cassette chunk: %v", err)
+	}
+	return data
+}
+
+// Key identifies the request a cassette answers: method, host, path, query and the body. JSON
+// bodies are canonicalized so that key order and whitespace do not matter.
+func Key(method, host, path, query string, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{strings.ToUpper(method), host, path, query} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(canonicalBody(body))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func canonicalBody(body []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return bytes.TrimSpace(body)
+	}
+	canonical, _ := json.Marshal(document)
+	return canonical
+}
+
+func (c *Cassette) Key() string {
+	return Key(c.Request.Method, c.Request.Host, c.Request.Path, c.Request.Query, []byte(c.Request.Body))
+}
+
+// Store keeps the cassettes of a directory in memory and writes newly recorded ones into it.
+type Store struct {
+	dir       string
+	mutex     sync.RWMutex
+	cassettes map[string]*Cassette
+}
+
+// Open loads every cassette file found in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cassette dir %s failed: %v", dir, err)
+	}
+	store := &Store{dir: dir, cassettes: make(map[string]*Cassette)}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read cassette %s failed: %v", file, err)
+		}
+		var c Cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("parse cassette %s failed: %v", file, err)
+		}
+		store.cassettes[c.Key()] = &c
+	}
+	log.Infof("loaded %d cassettes from %s", len(store.cassettes), dir)
+	return store, nil
+}
+
+// Find returns the cassette answering the request, or nil if none was recorded.
+func (s *Store) Find(key string) *Cassette {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.cassettes[key]
+}
+
+// Save writes the cassette into the store directory, replacing an earlier recording of the same request.
+func (s *Store) Save(c *Cassette) error {
+	key := c.Key()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fileNameReplacer.ReplaceAllString(c.Request.Host+c.Request.Path, "_")
+	file := filepath.Join(s.dir, fmt.Sprintf("%s_%s.json", strings.Trim(name, "_"), key[:12]))
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return fmt.Errorf("write cassette %s failed: %v", file, err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cassettes[key] = c
+	return nil
+}
+
+func redact(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range redactedHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, "REDACTED")
+		}
+	}
+	return header
+}
+
+func withoutHopHeaders(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+	return header
+}
@@ -0,0 +1,111 @@
+package cassette
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKey(t *testing.T) {
+	base := Key("POST", "api.openai.com", "/v1/chat/completions", "", []byte(`{"model":"gpt-4o","n":1}`))
+	tests := []struct {
+		name  string
+		key   string
+		equal bool
+	}{
+		{name: "json key order and whitespace", key: Key("post", "api.openai.com", "/v1/chat/completions", "", []byte("{\n  \"n\": 1, \"model\": \"gpt-4o\"\n}")), equal: true},
+		{name: "other body", key: Key("POST", "api.openai.com", "/v1/chat/completions", "", []byte(`{"model":"gpt-4","n":1}`))},
+		{name: "other host", key: Key("POST", "api.deepseek.com", "/v1/chat/completions", "", []byte(`{"model":"gpt-4o","n":1}`))},
+		{name: "other query", key: Key("POST", "api.openai.com", "/v1/chat/completions", "stream=true", []byte(`{"model":"gpt-4o","n":1}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.key == base) != tt.equal {
+				t.Errorf("Expected the keys to be equal: %t", tt.equal)
+			}
+		})
+	}
+
+	if Key("POST", "h", "/", "", []byte(" plain text \n")) != Key("POST", "h", "/", "", []byte("plain text")) {
+		t.Error("Expected non-JSON bodies to be matched without surrounding whitespace")
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("X-Request-Id", "upstream-1")
+		for _, frame := range []string{"data: {\"n\":1}\n\n", "data: [DONE]\n\n"} {
+			w.Write([]byte(frame))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	dir := t.TempDir()
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Failed to open cassette dir: %v", err)
+	}
+	recording := gin.New()
+	recording.NoRoute(NewRecorder(store, "http").Handle)
+	request := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","stream":true}`))
+	request.Host = host
+	request.Header.Set("Authorization", "Bearer sk-secret")
+	recorder := httptest.NewRecorder()
+	recording.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "data: {\"n\":1}\n\ndata: [DONE]\n\n" {
+		t.Fatalf("Expected the upstream response to be forwarded, got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one cassette file, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), "sk-secret") {
+		t.Error("Expected the credentials to be redacted from the cassette")
+	}
+
+	// A fresh store replays from the files alone.
+	store, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen cassette dir: %v", err)
+	}
+	replaying := gin.New()
+	replaying.Use(Replay(store, "/__admin"))
+	replaying.NoRoute(func(ctx *gin.Context) {
+		ctx.String(http.StatusNotFound, "mock")
+	})
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{name: "hit", body: `{"stream": true, "model": "gpt-4o"}`, status: http.StatusOK, expected: "data: {\"n\":1}\n\ndata: [DONE]\n\n"},
+		{name: "miss", body: `{"model":"gpt-4o-mini","stream":true}`, status: http.StatusNotFound, expected: "mock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tt.body))
+			request.Host = host
+			recorder := httptest.NewRecorder()
+			replaying.ServeHTTP(recorder, request)
+			if recorder.Code != tt.status || recorder.Body.String() != tt.expected {
+				t.Errorf("Expected %d %q, got %d %q", tt.status, tt.expected, recorder.Code, recorder.Body.String())
+			}
+			if tt.status == http.StatusOK && recorder.Header().Get("X-Request-Id") != "upstream-1" {
+				t.Errorf("Expected the recorded headers to be replayed, got %v", recorder.Header())
+			}
+		})
+	}
+}
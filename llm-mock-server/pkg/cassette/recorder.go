@@ -0,0 +1,105 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"llm-mock-server/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recorder forwards requests to the real upstream named by their Host header and saves every
+// request/response pair as a cassette.
+type Recorder struct {
+	store  *Store
+	scheme string
+	client *http.Client
+}
+
+func NewRecorder(store *Store, scheme string) *Recorder {
+	return &Recorder{
+		store:  store,
+		scheme: scheme,
+		// No client timeout: streaming responses may legitimately last for minutes.
+		client: &http.Client{},
+	}
+}
+
+func (r *Recorder) Handle(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Error reading request body"})
+		return
+	}
+	target := r.scheme + "://" + ctx.Request.Host + ctx.Request.URL.RequestURI()
+	upstreamRequest, err := http.NewRequestWithContext(ctx.Request.Context(), ctx.Request.Method, target, bytes.NewReader(body))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	upstreamRequest.Header = ctx.Request.Header.Clone()
+	// Ask for an identity-encoded body so the recorded chunks are readable and replayable as-is.
+	upstreamRequest.Header.Del("Accept-Encoding")
+
+	log.Infof("recording %s %s", ctx.Request.Method, target)
+	start := time.Now()
+	upstreamResponse, err := r.client.Do(upstreamRequest)
+	if err != nil {
+		log.Errorf("forward request to %s failed: %v", target, err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	defer upstreamResponse.Body.Close()
+
+	cassette := &Cassette{
+		RecordedAt: start,
+		Request: Request{
+			Method:  ctx.Request.Method,
+			Host:    ctx.Request.Host,
+			Path:    ctx.Request.URL.Path,
+			Query:   ctx.Request.URL.RawQuery,
+			Headers: redact(ctx.Request.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			Status:  upstreamResponse.StatusCode,
+			Headers: withoutHopHeaders(upstreamResponse.Header),
+		},
+	}
+
+	for name, values := range cassette.Response.Headers {
+		for _, value := range values {
+			ctx.Writer.Header().Add(name, value)
+		}
+	}
+	ctx.Status(upstreamResponse.StatusCode)
+	ctx.Writer.WriteHeaderNow()
+
+	// Every read from the upstream becomes a chunk, so the recording keeps the streaming cadence.
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := upstreamResponse.Body.Read(buf)
+		if n > 0 {
+			cassette.Response.Chunks = append(cassette.Response.Chunks, newChunk(time.Since(start), append([]byte{}, buf[:n]...)))
+			ctx.Writer.Write(buf[:n])
+			ctx.Writer.Flush()
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Errorf("read response from %s failed, the cassette is not saved: %v", target, err)
+			return
+		}
+	}
+
+	if err := r.store.Save(cassette); err != nil {
+		log.Errorf("save cassette failed: %v", err)
+		return
+	}
+	log.Infof("recorded %s %s with %d chunks", ctx.Request.Method, target, len(cassette.Response.Chunks))
+}
@@ -0,0 +1,59 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Replay serves the recorded response of matching requests with the original chunk timing and
+// hands the others over to the next handlers. Requests below skipPrefix are never replayed.
+func Replay(store *Store, skipPrefix string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if strings.HasPrefix(ctx.Request.URL.Path, skipPrefix) {
+			ctx.Next()
+			return
+		}
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Error reading request body"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		cassette := store.Find(Key(ctx.Request.Method, ctx.Request.Host, ctx.Request.URL.Path, ctx.Request.URL.RawQuery, body))
+		if cassette == nil {
+			ctx.Next()
+			return
+		}
+		ctx.Abort()
+		replay(ctx, cassette)
+	}
+}
+
+func replay(ctx *gin.Context, cassette *Cassette) {
+	for name, values := range cassette.Response.Headers {
+		for _, value := range values {
+			ctx.Writer.Header().Add(name, value)
+		}
+	}
+	ctx.Status(cassette.Response.Status)
+	ctx.Writer.WriteHeaderNow()
+
+	start := time.Now()
+	for _, chunk := range cassette.Response.Chunks {
+		if wait := chunk.Offset.Duration() - time.Since(start); wait > 0 {
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		ctx.Writer.Write(chunk.Bytes())
+		ctx.Writer.Flush()
+	}
+}
@@ -1,14 +1,34 @@
 package options
 
 import (
+	"llm-mock-server/pkg/journal"
+	"llm-mock-server/pkg/provider/chat"
+
 	"github.com/spf13/pflag"
 )
 
 type Option struct {
 	ServerPort   uint32
 	ProviderType string
+	ConfigFile   string
+	JournalSize  int
+	// ResponsesSize is the number of responses the Responses API keeps.
+	ResponsesSize int
+
+	Mode           string
+	CassetteDir    string
+	UpstreamScheme string
 }
 
+const (
+	// ModeMock serves the built-in provider mocks.
+	ModeMock = "mock"
+	// ModeRecord forwards requests to the real providers and records them as cassettes.
+	ModeRecord = "record"
+	// ModeReplay serves the recorded cassettes, falling back to the provider mocks.
+	ModeReplay = "replay"
+)
+
 func NewOption() *Option {
 	return &Option{}
 }
@@ -16,4 +36,10 @@ func NewOption() *Option {
 func (o *Option) AddFlags(flags *pflag.FlagSet) {
 	flags.Uint32Var(&o.ServerPort, "server-port", 3000, "The server port binds to.")
 	flags.StringVar(&o.ProviderType, "provider-type", "", "The provider type to use. If not specified, all routes will be enabled.")
+	flags.StringVar(&o.ConfigFile, "config", "", "The YAML/JSON file declaring the providers, routes and mock behaviour.")
+	flags.IntVar(&o.JournalSize, "journal-size", journal.DefaultSize, "The number of requests kept in the request journal.")
+	flags.IntVar(&o.ResponsesSize, "responses-size", chat.DefaultResponseStoreSize, "The number of responses kept by the Responses API.")
+	flags.StringVar(&o.Mode, "mode", ModeMock, "The server mode: mock, record (forward to the real providers and record cassettes) or replay (serve the recorded cassettes).")
+	flags.StringVar(&o.CassetteDir, "cassette-dir", "cassettes", "The directory the cassettes are recorded into and replayed from.")
+	flags.StringVar(&o.UpstreamScheme, "upstream-scheme", "https", "The scheme used to reach the real providers in record mode.")
 }
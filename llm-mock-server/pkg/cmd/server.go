@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"os"
 
+	"llm-mock-server/pkg/admin"
+	"llm-mock-server/pkg/apikey"
+	"llm-mock-server/pkg/cassette"
 	"llm-mock-server/pkg/cmd/options"
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/journal"
 	"llm-mock-server/pkg/log"
 	"llm-mock-server/pkg/middleware"
 	"llm-mock-server/pkg/provider/chat"
 	"llm-mock-server/pkg/provider/embeddings"
+	"llm-mock-server/pkg/provider/rerank"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -35,16 +41,61 @@ func Run(option *options.Option) error {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	cfg, err := config.Load(option.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	apikey.DefaultRegistry.Load(cfg.APIKeys)
+
 	server := gin.New()
 	server.Use(middleware.CORS())
-	middleware.StartLogger(server, option)
+	middleware.StartLogger(server)
 
-	// Set up chat completion routes
-	chat.SetupRoutes(server, option.ProviderType)
+	// Record every request so that tests can assert on what the mock received
+	journal.DefaultJournal.Resize(option.JournalSize)
+	server.Use(middleware.Journal(journal.DefaultJournal, admin.PathPrefix))
 
-	// embeddings
-	server.POST("/v1/embeddings", embeddings.HandleEmbeddings)
+	// Admin API for controlling the mock at runtime
+	responses := chat.NewResponseStore(option.ResponsesSize)
+	admin.SetupRoutes(server, responses)
+
+	switch option.Mode {
+	case options.ModeRecord:
+		store, err := cassette.Open(option.CassetteDir)
+		if err != nil {
+			return err
+		}
+		// Every non-admin request is forwarded to the real upstream and recorded
+		server.NoRoute(cassette.NewRecorder(store, option.UpstreamScheme).Handle)
+	case options.ModeReplay:
+		store, err := cassette.Open(option.CassetteDir)
+		if err != nil {
+			return err
+		}
+		// Recorded requests are replayed, the others reach the provider mocks
+		server.Use(cassette.Replay(store, admin.PathPrefix))
+		setupMockRoutes(server, option, cfg, responses)
+	case options.ModeMock:
+		setupMockRoutes(server, option, cfg, responses)
+	default:
+		return fmt.Errorf("unknown mode: %s", option.Mode)
+	}
 
 	log.Infof("Starting server on port %d", option.ServerPort)
 	return server.Run(fmt.Sprintf(":%d", option.ServerPort))
 }
+
+func setupMockRoutes(server *gin.Engine, option *options.Option, cfg *config.Config, responses *chat.ResponseStore) {
+	// The X-Mock-* control headers apply to the mock routes registered below, not to the admin API
+	server.Use(middleware.MockControl(cfg))
+
+	// Set up chat completion routes; their Gemini and Vertex model routes also serve the embeddings actions
+	chat.SetupRoutes(server, option.ProviderType, cfg, responses, embeddings.ModelActions())
+
+	// embeddings
+	embeddings.SetupRoutes(server, cfg)
+
+	// rerank
+	rerank.SetupRoutes(server)
+}
@@ -0,0 +1,42 @@
+package config
+
+import "fmt"
+
+// API key states.
+const (
+	KeyValid          = "valid"
+	KeyInvalid        = "invalid"
+	KeyExpired        = "expired"
+	KeyQuotaExhausted = "quotaExhausted"
+)
+
+type APIKeyConfig struct {
+	// Strict treats the keys that are not registered (including missing keys) as invalid.
+	// Otherwise they are accepted.
+	Strict bool     `json:"strict,omitempty" yaml:"strict,omitempty"`
+	Keys   []APIKey `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// APIKey is a registered API key and the way providers treat the requests authenticated with it.
+type APIKey struct {
+	Key string `json:"key" yaml:"key"`
+	// State is valid (the default), invalid, expired or quotaExhausted.
+	State string `json:"state,omitempty" yaml:"state,omitempty"`
+	// Models lists the models the key may use. An empty list allows every model.
+	Models []string `json:"models,omitempty" yaml:"models,omitempty"`
+}
+
+// Validate checks the key and its state, defaulting the state to valid.
+func (k *APIKey) Validate() error {
+	if k.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	switch k.State {
+	case "":
+		k.State = KeyValid
+	case KeyValid, KeyInvalid, KeyExpired, KeyQuotaExhausted:
+	default:
+		return fmt.Errorf("key %s: unknown state %q", k.Key, k.State)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative mock behaviour loaded from the --config file. The file may be
+// written in YAML or JSON (JSON is accepted by the YAML decoder as-is).
+type Config struct {
+	// Routes overrides the built-in chat completion route list when not empty.
+	Routes []string `json:"routes,omitempty" yaml:"routes,omitempty"`
+	// Providers holds the per-provider behaviour, keyed by provider name (openai, claude, qwen...).
+	Providers map[string]*ProviderConfig `json:"providers,omitempty" yaml:"providers,omitempty"`
+	// Faults are injected into the requests of every provider.
+	Faults *FaultConfig `json:"faults,omitempty" yaml:"faults,omitempty"`
+	// RateLimit limits every provider, per API key and model.
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	// APIKeys registers API keys with a per-key behaviour.
+	APIKeys *APIKeyConfig `json:"apiKeys,omitempty" yaml:"apiKeys,omitempty"`
+	// Scenarios are named behaviours selected per request with the X-Mock-Scenario header.
+	Scenarios map[string]*Scenario `json:"scenarios,omitempty" yaml:"scenarios,omitempty"`
+	// Embeddings selects how the embeddings are computed.
+	Embeddings *EmbeddingsConfig `json:"embeddings,omitempty" yaml:"embeddings,omitempty"`
+}
+
+// ProviderConfig declares how a single provider mock behaves.
+type ProviderConfig struct {
+	// Enabled turns the provider off when set to false. Providers are enabled by default.
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Models lists the accepted model ids. An empty list accepts every model.
+	Models []string `json:"models,omitempty" yaml:"models,omitempty"`
+	// Reply is a canned reply returned instead of echoing the prompt.
+	Reply string `json:"reply,omitempty" yaml:"reply,omitempty"`
+	// Latency is added before the provider starts responding.
+	Latency Duration `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// ChunkDelay is the delay between two streaming chunks. Zero keeps the provider default.
+	ChunkDelay Duration `json:"chunkDelay,omitempty" yaml:"chunkDelay,omitempty"`
+	// Usage overrides the token usage reported by the provider.
+	Usage *Usage `json:"usage,omitempty" yaml:"usage,omitempty"`
+	// Generator generates the reply instead of echoing the prompt.
+	Generator *GeneratorConfig `json:"generator,omitempty" yaml:"generator,omitempty"`
+	// Tokenizer names the tokenizer counting the usage (cl100k, o200k, claude, gemini or qwen).
+	// By default it is chosen from the model of the request.
+	Tokenizer string `json:"tokenizer,omitempty" yaml:"tokenizer,omitempty"`
+	// Error makes the provider fail with the given status instead of replying. It is a shorthand
+	// for faults.error.
+	Error *ErrorConfig `json:"error,omitempty" yaml:"error,omitempty"`
+	// Faults are injected into the requests of this provider, overriding the global faults.
+	Faults *FaultConfig `json:"faults,omitempty" yaml:"faults,omitempty"`
+	// RateLimit limits this provider instead of the global rate limit.
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"promptTokens" yaml:"promptTokens"`
+	CompletionTokens int `json:"completionTokens" yaml:"completionTokens"`
+	TotalTokens      int `json:"totalTokens,omitempty" yaml:"totalTokens,omitempty"`
+}
+
+// IsEnabled reports whether the provider is enabled. A nil config means the provider is not
+// mentioned in the config file and keeps its default (enabled) behaviour.
+func (p *ProviderConfig) IsEnabled() bool {
+	return p == nil || p.Enabled == nil || *p.Enabled
+}
+
+// AcceptsModel reports whether the model id is accepted by the provider.
+func (p *ProviderConfig) AcceptsModel(model string) bool {
+	if p == nil || len(p.Models) == 0 {
+		return true
+	}
+	for _, m := range p.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider returns the config of the named provider, or nil if the provider is not configured.
+func (c *Config) Provider(name string) *ProviderConfig {
+	if c == nil {
+		return nil
+	}
+	return c.Providers[strings.ToLower(name)]
+}
+
+// Load reads the config file at path. An empty path yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file %s failed: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %s failed: %v", path, err)
+	}
+	if err := cfg.normalize(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// normalize lower-cases the provider names and validates the provider configs.
+func (c *Config) normalize() error {
+	if err := c.Faults.Validate(); err != nil {
+		return fmt.Errorf("faults: %v", err)
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("rateLimit: %v", err)
+	}
+	if c.APIKeys != nil {
+		for i := range c.APIKeys.Keys {
+			if err := c.APIKeys.Keys[i].Validate(); err != nil {
+				return fmt.Errorf("apiKeys: %v", err)
+			}
+		}
+	}
+	if err := c.Embeddings.Validate(); err != nil {
+		return fmt.Errorf("embeddings: %v", err)
+	}
+	for name, scenario := range c.Scenarios {
+		if err := scenario.Validate(); err != nil {
+			return fmt.Errorf("scenario %s: %v", name, err)
+		}
+	}
+	providers := make(map[string]*ProviderConfig, len(c.Providers))
+	for name, provider := range c.Providers {
+		if provider == nil {
+			provider = &ProviderConfig{}
+		}
+		if provider.Error != nil {
+			if err := provider.Error.Validate(); err != nil {
+				return fmt.Errorf("provider %s: %v", name, err)
+			}
+		}
+		if err := provider.Faults.Validate(); err != nil {
+			return fmt.Errorf("provider %s: faults: %v", name, err)
+		}
+		if err := provider.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("provider %s: rateLimit: %v", name, err)
+		}
+		if err := provider.Generator.Validate(); err != nil {
+			return fmt.Errorf("provider %s: generator: %v", name, err)
+		}
+		if provider.Tokenizer != "" && tokenizer.Get(provider.Tokenizer) == nil {
+			return fmt.Errorf("provider %s: unknown tokenizer %q, expected one of %s",
+				name, provider.Tokenizer, strings.Join(tokenizer.Names(), ", "))
+		}
+		if provider.Usage != nil && provider.Usage.TotalTokens == 0 {
+			provider.Usage.TotalTokens = provider.Usage.PromptTokens + provider.Usage.CompletionTokens
+		}
+		providers[strings.ToLower(name)] = provider
+	}
+	c.Providers = providers
+	return nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected func(*Config) bool
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+routes:
+  - /v1/chat/completions
+providers:
+  OpenAI:
+    models: [gpt-4o]
+    reply: canned
+    latency: 200ms
+    usage:
+      promptTokens: 3
+      completionTokens: 4
+  claude:
+    enabled: false
+`,
+			expected: func(c *Config) bool {
+				openai := c.Provider("openai")
+				return len(c.Routes) == 1 &&
+					openai.Reply == "canned" &&
+					openai.Latency.Duration() == 200*time.Millisecond &&
+					openai.Usage.TotalTokens == 7 &&
+					openai.AcceptsModel("gpt-4o") && !openai.AcceptsModel("gpt-4") &&
+					!c.Provider("claude").IsEnabled() &&
+					c.Provider("gemini").IsEnabled()
+			},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"providers": {"qwen": {"chunkDelay": "1s", "error": {"status": 503, "probability": 0.5}}}}`,
+			expected: func(c *Config) bool {
+				qwen := c.Provider("qwen")
+				return qwen.ChunkDelay.Duration() == time.Second &&
+					qwen.Error.Status == 503 && *qwen.Error.Probability == 0.5
+			},
+		},
+		{
+			name:    "zero probability",
+			file:    "config.yaml",
+			content: "providers:\n  claude:\n    error: {status: 529, probability: 0}\n",
+			expected: func(c *Config) bool {
+				// An explicit zero never fails, unlike an unset probability.
+				probability := c.Provider("claude").Error.Probability
+				return probability != nil && *probability == 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if !tt.expected(cfg) {
+				t.Errorf("Test failed for %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid duration", content: "providers: {openai: {latency: soon}}"},
+		{name: "non-error status", content: "providers: {openai: {error: {status: 200}}}"},
+		{name: "probability out of range", content: "providers: {openai: {error: {status: 500, probability: 2}}}"},
+		{name: "invalid scenario", content: "scenarios: {broken: {chunks: -1}}"},
+		{name: "invalid fault", content: "faults: {resetAfterChunks: {chunks: 1, probability: 1.5}}"},
+		{name: "unknown tokenizer", content: "providers: {openai: {tokenizer: llama}}"},
+		{name: "unknown generator", content: "providers: {openai: {generator: {strategy: gpt}}}"},
+		{name: "invalid template", content: "providers: {openai: {generator: {strategy: template, text: \"{{.Prompt\"}}}}"},
+		{name: "unknown embedding mode", content: "embeddings: {mode: word2vec}"},
+		{name: "single synonym", content: "embeddings: {mode: semantic, synonyms: [[reset password]]}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, "config.yaml", tt.content)); err == nil {
+				t.Errorf("Expected error for %s, but got none", tt.name)
+			}
+		})
+	}
+}
+
+func TestScenarioMerge(t *testing.T) {
+	base := &Scenario{Status: 529, Message: "Overloaded", Chunks: 2, Delay: Duration(time.Second)}
+	merged := base.Merge(&Scenario{Status: 429, Reply: "hi"})
+	if merged.Status != 429 || merged.Message != "" || merged.Chunks != 2 || merged.Reply != "hi" {
+		t.Errorf("Unexpected merged scenario: %+v", merged)
+	}
+	faults := merged.FaultConfig()
+	if faults.Error == nil || faults.Error.Status != 429 || faults.Latency == nil || faults.Latency.Delay != Duration(time.Second) {
+		t.Errorf("Unexpected scenario faults: %+v", faults)
+	}
+	if base.Status != 529 {
+		t.Error("Expected the merge to leave the base scenario untouched")
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Embedding modes.
+const (
+	EmbeddingRandom   = "random"
+	EmbeddingSemantic = "semantic"
+)
+
+// EmbeddingsConfig selects how the embeddings are computed.
+type EmbeddingsConfig struct {
+	// Mode is random (the default), where every text gets an unrelated vector, or semantic, where
+	// the cosine similarity of two vectors follows the overlap of their words.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Synonyms are groups of phrases that get the same vector features in the semantic mode, e.g.
+	// ["reset my password", "change my passcode"].
+	Synonyms [][]string `json:"synonyms,omitempty" yaml:"synonyms,omitempty"`
+}
+
+// Validate checks the mode and the synonym groups.
+func (e *EmbeddingsConfig) Validate() error {
+	if e == nil {
+		return nil
+	}
+	switch e.Mode {
+	case "", EmbeddingRandom, EmbeddingSemantic:
+	default:
+		return fmt.Errorf("unknown mode %q, expected %s or %s", e.Mode, EmbeddingRandom, EmbeddingSemantic)
+	}
+	for i, group := range e.Synonyms {
+		if len(group) < 2 {
+			return fmt.Errorf("synonym group %d must have at least 2 phrases", i)
+		}
+		for _, phrase := range group {
+			if strings.TrimSpace(phrase) == "" {
+				return fmt.Errorf("synonym group %d has an empty phrase", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import "fmt"
+
+type ErrorConfig struct {
+	Status  int    `json:"status" yaml:"status"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Probability is the chance in [0, 1] that a request fails. Unset means every request fails,
+	// zero means none does.
+	Probability *float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+}
+
+// FaultConfig declares the faults injected into responses. Every fault fires independently with
+// its own probability in [0, 1]; an unset probability means every request.
+type FaultConfig struct {
+	// Error fails the request with an HTTP error status rendered in the provider's native shape.
+	Error *ErrorConfig `json:"error,omitempty" yaml:"error,omitempty"`
+	// Latency delays the response.
+	Latency *LatencyFault `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// ResetBeforeHeaders resets the connection before any response header is written.
+	ResetBeforeHeaders *ProbabilityFault `json:"resetBeforeHeaders,omitempty" yaml:"resetBeforeHeaders,omitempty"`
+	// ResetAfterChunks resets the connection once the given number of stream chunks was sent.
+	ResetAfterChunks *ChunkFault `json:"resetAfterChunks,omitempty" yaml:"resetAfterChunks,omitempty"`
+	// TruncateFrame sends only the first half of the stream frame following the given number of
+	// chunks, then resets the connection.
+	TruncateFrame *ChunkFault `json:"truncateFrame,omitempty" yaml:"truncateFrame,omitempty"`
+	// MalformedJson cuts the JSON document of the response body (or of one stream frame) in half.
+	MalformedJson *ProbabilityFault `json:"malformedJson,omitempty" yaml:"malformedJson,omitempty"`
+}
+
+type ProbabilityFault struct {
+	Probability *float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+}
+
+type LatencyFault struct {
+	Delay Duration `json:"delay" yaml:"delay"`
+	// Jitter spreads the delay uniformly within [delay-jitter, delay+jitter].
+	Jitter      Duration `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	Probability *float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+}
+
+type ChunkFault struct {
+	Chunks      int      `json:"chunks" yaml:"chunks"`
+	Probability *float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+}
+
+// Merge returns the faults of c overridden, fault by fault, by the ones set in override.
+func (c *FaultConfig) Merge(override *FaultConfig) *FaultConfig {
+	if c == nil {
+		return override
+	}
+	if override == nil {
+		return c
+	}
+	merged := *c
+	if override.Error != nil {
+		merged.Error = override.Error
+	}
+	if override.Latency != nil {
+		merged.Latency = override.Latency
+	}
+	if override.ResetBeforeHeaders != nil {
+		merged.ResetBeforeHeaders = override.ResetBeforeHeaders
+	}
+	if override.ResetAfterChunks != nil {
+		merged.ResetAfterChunks = override.ResetAfterChunks
+	}
+	if override.TruncateFrame != nil {
+		merged.TruncateFrame = override.TruncateFrame
+	}
+	if override.MalformedJson != nil {
+		merged.MalformedJson = override.MalformedJson
+	}
+	return &merged
+}
+
+// Validate checks the statuses, chunk counts and probabilities of the faults.
+func (c *FaultConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	probabilities := map[string]*float64{}
+	if c.Error != nil {
+		if err := c.Error.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Latency != nil {
+		probabilities["latency"] = c.Latency.Probability
+	}
+	if c.ResetBeforeHeaders != nil {
+		probabilities["resetBeforeHeaders"] = c.ResetBeforeHeaders.Probability
+	}
+	if c.MalformedJson != nil {
+		probabilities["malformedJson"] = c.MalformedJson.Probability
+	}
+	for name, fault := range map[string]*ChunkFault{"resetAfterChunks": c.ResetAfterChunks, "truncateFrame": c.TruncateFrame} {
+		if fault == nil {
+			continue
+		}
+		if fault.Chunks < 0 {
+			return fmt.Errorf("%s: chunks must not be negative", name)
+		}
+		probabilities[name] = fault.Probability
+	}
+	for name, probability := range probabilities {
+		if probability != nil && (*probability < 0 || *probability > 1) {
+			return fmt.Errorf("%s: probability must be within [0, 1]", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the status is an HTTP error status and the probability is within [0, 1].
+func (e *ErrorConfig) Validate() error {
+	if e.Status < 400 || e.Status > 599 {
+		return fmt.Errorf("error status %d is not an HTTP error status", e.Status)
+	}
+	if e.Probability != nil && (*e.Probability < 0 || *e.Probability > 1) {
+		return fmt.Errorf("error probability must be within [0, 1]")
+	}
+	return nil
+}
+
+// FaultConfig returns the faults injected into the provider, with the error shorthand folded in.
+func (p *ProviderConfig) FaultConfig() *FaultConfig {
+	if p == nil || (p.Error == nil && p.Faults == nil) {
+		return nil
+	}
+	return (&FaultConfig{Error: p.Error}).Merge(p.Faults)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Reply generation strategies.
+const (
+	GeneratorEcho     = "echo"
+	GeneratorFixed    = "fixed"
+	GeneratorLorem    = "lorem"
+	GeneratorReverse  = "reverse"
+	GeneratorTemplate = "template"
+	GeneratorMarkov   = "markov"
+)
+
+// GeneratorStrategies lists the reply generation strategies.
+var GeneratorStrategies = []string{GeneratorEcho, GeneratorFixed, GeneratorLorem, GeneratorReverse, GeneratorTemplate, GeneratorMarkov}
+
+// maxGeneratedWords bounds the length of the generated replies.
+const maxGeneratedWords = 100000
+
+// GeneratorConfig selects how the reply of a provider is generated.
+type GeneratorConfig struct {
+	// Strategy is echo, fixed, lorem, reverse, template or markov.
+	Strategy string `json:"strategy" yaml:"strategy"`
+	// Text is the reply of the fixed strategy, and the Go template of the template strategy, which
+	// is given the .Prompt and the .Model of the request and the .Index of the choice.
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+	// Length is the number of words of the lorem and markov replies.
+	Length int `json:"length,omitempty" yaml:"length,omitempty"`
+	// Seed makes the lorem and markov replies the same for every prompt. By default they are
+	// seeded with the prompt, so that a prompt always gets the same reply.
+	Seed int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
+// Validate checks the strategy, the length and the template of the generator.
+func (g *GeneratorConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+	known := false
+	for _, strategy := range GeneratorStrategies {
+		known = known || g.Strategy == strategy
+	}
+	if !known {
+		return fmt.Errorf("unknown strategy %q, expected one of %s", g.Strategy, strings.Join(GeneratorStrategies, ", "))
+	}
+	if g.Length < 0 || g.Length > maxGeneratedWords {
+		return fmt.Errorf("length must be between 0 and %d", maxGeneratedWords)
+	}
+	if g.Strategy == GeneratorTemplate {
+		if _, err := template.New("reply").Parse(g.Text); err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import "fmt"
+
+// RateLimitConfig is the budget of a token bucket rate limiter. Zero means no limit.
+type RateLimitConfig struct {
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty" yaml:"requestsPerMinute,omitempty"`
+	TokensPerMinute   int `json:"tokensPerMinute,omitempty" yaml:"tokensPerMinute,omitempty"`
+}
+
+// Validate checks that the budgets are not negative.
+func (r *RateLimitConfig) Validate() error {
+	if r != nil && (r.RequestsPerMinute < 0 || r.TokensPerMinute < 0) {
+		return fmt.Errorf("budgets must not be negative")
+	}
+	return nil
+}
+
+// RateLimitOf returns the rate limit of the named provider, falling back to the global rate limit.
+func (c *Config) RateLimitOf(name string) *RateLimitConfig {
+	if c == nil {
+		return nil
+	}
+	if provider := c.Provider(name); provider != nil && provider.RateLimit != nil {
+		return provider.RateLimit
+	}
+	return c.RateLimit
+}
@@ -0,0 +1,100 @@
+package config
+
+import "fmt"
+
+// Scenario overrides the behaviour of a single request. It is selected by name through the
+// X-Mock-Scenario header, built from the X-Mock-* headers or parsed from a __mock__ prompt directive.
+type Scenario struct {
+	// Status fails the request with the given HTTP error status in the provider's native shape.
+	Status  int    `json:"status,omitempty" yaml:"status,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Delay is waited before the provider responds.
+	Delay      Duration `json:"delay,omitempty" yaml:"delay,omitempty"`
+	ChunkDelay Duration `json:"chunkDelay,omitempty" yaml:"chunkDelay,omitempty"`
+	// Chunks is the number of stream chunks the reply is split into.
+	Chunks int `json:"chunks,omitempty" yaml:"chunks,omitempty"`
+	// Finish is the finish reason: stop, length, tool_calls, content_filter, or a provider-native value.
+	Finish string `json:"finish,omitempty" yaml:"finish,omitempty"`
+	Reply  string `json:"reply,omitempty" yaml:"reply,omitempty"`
+	// Generator generates the reply, overriding the configured generator.
+	Generator *GeneratorConfig `json:"generator,omitempty" yaml:"generator,omitempty"`
+	// Faults are injected into the request, overriding the configured ones.
+	Faults *FaultConfig `json:"faults,omitempty" yaml:"faults,omitempty"`
+}
+
+// Validate checks the status, the chunk count and the faults of the scenario.
+func (s *Scenario) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Status != 0 && (s.Status < 400 || s.Status > 599) {
+		return fmt.Errorf("status %d is not an HTTP error status", s.Status)
+	}
+	if s.Chunks < 0 {
+		return fmt.Errorf("chunks must not be negative")
+	}
+	if err := s.Generator.Validate(); err != nil {
+		return fmt.Errorf("generator: %v", err)
+	}
+	if err := s.Faults.Validate(); err != nil {
+		return fmt.Errorf("faults: %v", err)
+	}
+	return nil
+}
+
+// Merge returns the scenario s overridden, field by field, by the ones set in override.
+func (s *Scenario) Merge(override *Scenario) *Scenario {
+	if s == nil {
+		return override
+	}
+	if override == nil {
+		return s
+	}
+	merged := *s
+	if override.Status != 0 {
+		merged.Status, merged.Message = override.Status, override.Message
+	}
+	if override.Delay > 0 {
+		merged.Delay = override.Delay
+	}
+	if override.ChunkDelay > 0 {
+		merged.ChunkDelay = override.ChunkDelay
+	}
+	if override.Chunks > 0 {
+		merged.Chunks = override.Chunks
+	}
+	if override.Finish != "" {
+		merged.Finish = override.Finish
+	}
+	if override.Reply != "" {
+		merged.Reply = override.Reply
+	}
+	if override.Generator != nil {
+		merged.Generator = override.Generator
+	}
+	merged.Faults = s.Faults.Merge(override.Faults)
+	return &merged
+}
+
+// FaultConfig returns the status and delay of the scenario as faults, merged with its explicit faults.
+func (s *Scenario) FaultConfig() *FaultConfig {
+	if s == nil {
+		return nil
+	}
+	faults := &FaultConfig{}
+	if s.Status != 0 {
+		faults.Error = &ErrorConfig{Status: s.Status, Message: s.Message}
+	}
+	if s.Delay > 0 {
+		faults.Latency = &LatencyFault{Delay: s.Delay}
+	}
+	return faults.Merge(s.Faults)
+}
+
+// Scenario returns the named scenario, or nil if there is no such scenario.
+func (c *Config) Scenario(name string) *Scenario {
+	if c == nil {
+		return nil
+	}
+	return c.Scenarios[name]
+}
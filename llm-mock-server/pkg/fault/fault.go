@@ -0,0 +1,194 @@
+package fault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header carries per-request faults as a JSON fault config, e.g.
+// X-Mock-Fault: {"resetAfterChunks": {"chunks": 3}}. They override the configured faults.
+const Header = "X-Mock-Fault"
+
+// disabled marks a chunk fault that did not fire.
+const disabled = -1
+
+// Plan is the outcome of rolling the dice of a fault config for a single request.
+type Plan struct {
+	// Error is the error the request fails with, or nil.
+	Error *config.ErrorConfig
+	// Delay is waited before the request is handled.
+	Delay time.Duration
+	// ResetBeforeHeaders resets the connection without writing any response.
+	ResetBeforeHeaders bool
+	// ResetAfterChunks is the number of stream chunks sent before the connection is reset, or -1.
+	ResetAfterChunks int
+	// TruncateAfterChunks is the number of stream chunks sent before a truncated one, or -1.
+	TruncateAfterChunks int
+	// MalformedJson corrupts the first JSON object written.
+	MalformedJson bool
+}
+
+// FromRequest returns the faults requested through the X-Mock-Fault header, or nil if there are none.
+func FromRequest(request *http.Request) (*config.FaultConfig, error) {
+	value := request.Header.Get(Header)
+	if value == "" {
+		return nil, nil
+	}
+	faults := &config.FaultConfig{}
+	if err := json.Unmarshal([]byte(value), faults); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %v", Header, err)
+	}
+	if err := faults.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %v", Header, err)
+	}
+	return faults, nil
+}
+
+// Decide rolls every fault of the config independently.
+func Decide(faults *config.FaultConfig) Plan {
+	plan := Plan{ResetAfterChunks: disabled, TruncateAfterChunks: disabled}
+	if faults == nil {
+		return plan
+	}
+	if faults.Error != nil && Hit(faults.Error.Probability) {
+		plan.Error = faults.Error
+	}
+	if faults.Latency != nil && Hit(faults.Latency.Probability) {
+		plan.Delay = jittered(faults.Latency.Delay.Duration(), faults.Latency.Jitter.Duration())
+	}
+	if faults.ResetBeforeHeaders != nil && Hit(faults.ResetBeforeHeaders.Probability) {
+		plan.ResetBeforeHeaders = true
+	}
+	if faults.ResetAfterChunks != nil && Hit(faults.ResetAfterChunks.Probability) {
+		plan.ResetAfterChunks = faults.ResetAfterChunks.Chunks
+	}
+	if faults.TruncateFrame != nil && Hit(faults.TruncateFrame.Probability) {
+		plan.TruncateAfterChunks = faults.TruncateFrame.Chunks
+	}
+	if faults.MalformedJson != nil && Hit(faults.MalformedJson.Probability) {
+		plan.MalformedJson = true
+	}
+	return plan
+}
+
+// CorruptsBody reports whether the plan needs the response writer to be wrapped by Wrap.
+func (p Plan) CorruptsBody() bool {
+	return p.ResetAfterChunks != disabled || p.TruncateAfterChunks != disabled || p.MalformedJson
+}
+
+// Hit reports whether a fault with the given probability fires. An unset probability always fires.
+func Hit(probability *float64) bool {
+	return probability == nil || *probability >= 1 || rand.Float64() < *probability
+}
+
+// jittered returns a delay picked uniformly within [delay-jitter, delay+jitter], never negative.
+func jittered(delay, jitter time.Duration) time.Duration {
+	if jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(2*jitter)+1)) - jitter
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Reset aborts the connection of the response. The TCP connection is closed with a zero linger
+// time so that the client sees a connection reset rather than a clean end of file.
+func Reset(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		log.Errorf("reset connection failed: the response writer cannot be hijacked")
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Errorf("reset connection failed: %v", err)
+		return
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+}
+
+// Writer applies the stream faults of a plan to the response. A stream chunk is whatever the
+// provider writes between two flushes.
+type Writer struct {
+	gin.ResponseWriter
+	plan    Plan
+	chunks  int
+	corrupt bool
+	reset   bool
+}
+
+// Wrap installs a fault writer as the response writer of the request.
+func Wrap(ctx *gin.Context, plan Plan) {
+	ctx.Writer = &Writer{ResponseWriter: ctx.Writer, plan: plan, corrupt: plan.MalformedJson}
+}
+
+func (w *Writer) Write(data []byte) (int, error) {
+	if w.reset {
+		return 0, net.ErrClosed
+	}
+	if w.chunks == w.plan.ResetAfterChunks {
+		// Let the headers and the chunks sent so far reach the client before the reset.
+		w.ResponseWriter.Flush()
+		w.abort()
+		return 0, net.ErrClosed
+	}
+	if w.corrupt {
+		if corrupted, ok := corruptJson(data); ok {
+			w.corrupt = false
+			data = corrupted
+		}
+	}
+	if w.chunks == w.plan.TruncateAfterChunks {
+		w.ResponseWriter.Write(data[:len(data)/2])
+		w.ResponseWriter.Flush()
+		w.abort()
+		return 0, net.ErrClosed
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *Writer) Flush() {
+	if w.reset {
+		return
+	}
+	w.chunks++
+	w.ResponseWriter.Flush()
+}
+
+func (w *Writer) abort() {
+	log.Infof("fault injected: connection reset after %d chunks", w.chunks)
+	w.reset = true
+	Reset(w.ResponseWriter)
+}
+
+// corruptJson cuts the outermost JSON object found in data in half, keeping what surrounds
+// it (such as the SSE framing) intact. It reports false if data holds no JSON object.
+func corruptJson(data []byte) ([]byte, bool) {
+	start := bytes.IndexByte(data, '{')
+	end := bytes.LastIndexByte(data, '}')
+	if start < 0 || end <= start {
+		return nil, false
+	}
+	corrupted := make([]byte, 0, len(data))
+	corrupted = append(corrupted, data[:start+(end+1-start)/2]...)
+	corrupted = append(corrupted, data[end+1:]...)
+	return corrupted, true
+}
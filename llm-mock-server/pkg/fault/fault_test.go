@@ -0,0 +1,72 @@
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"llm-mock-server/pkg/config"
+)
+
+func TestCorruptJson(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+		ok       bool
+	}{
+		{name: "body", data: `{"a":"bcdef"}`, expected: `{"a":"`, ok: true},
+		{name: "sse frame keeps framing", data: "event: ping\ndata: {\"n\":12345}\n\n", expected: "event: ping\ndata: {\"n\":\n\n", ok: true},
+		{name: "no json", data: "data: [DONE]\n\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted, ok := corruptJson([]byte(tt.data))
+			if ok != tt.ok || (ok && string(corrupted) != tt.expected) {
+				t.Errorf("Expected (%q, %t), got (%q, %t)", tt.expected, tt.ok, corrupted, ok)
+			}
+		})
+	}
+}
+
+func TestDecideMergesOverrides(t *testing.T) {
+	global := &config.FaultConfig{
+		Error:   &config.ErrorConfig{Status: 503},
+		Latency: &config.LatencyFault{Delay: config.Duration(time.Second), Jitter: config.Duration(100 * time.Millisecond)},
+	}
+	request := &config.FaultConfig{Error: &config.ErrorConfig{Status: 429}, ResetAfterChunks: &config.ChunkFault{Chunks: 2}}
+
+	plan := Decide(global.Merge(request))
+	if plan.Error == nil || plan.Error.Status != 429 {
+		t.Errorf("Expected the request error to override the global one, got %+v", plan.Error)
+	}
+	if plan.Delay < 900*time.Millisecond || plan.Delay > 1100*time.Millisecond {
+		t.Errorf("Expected the delay within the jitter, got %v", plan.Delay)
+	}
+	if plan.ResetAfterChunks != 2 || plan.TruncateAfterChunks != disabled || plan.MalformedJson {
+		t.Errorf("Unexpected stream faults: %+v", plan)
+	}
+}
+
+func TestHit(t *testing.T) {
+	probability := func(p float64) *float64 { return &p }
+	tests := []struct {
+		name        string
+		probability *float64
+		expected    bool
+	}{
+		{name: "unset", expected: true},
+		{name: "zero", probability: probability(0), expected: false},
+		{name: "one", probability: probability(1), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := Hit(tt.probability); got != tt.expected {
+					t.Fatalf("Expected %t, got %t", tt.expected, got)
+				}
+			}
+		})
+	}
+}
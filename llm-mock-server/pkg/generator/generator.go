@@ -0,0 +1,210 @@
+package generator
+
+import (
+	_ "embed"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+
+	"llm-mock-server/pkg/config"
+)
+
+// defaultLength is the number of words of the lorem and markov replies when no length is set.
+const defaultLength = 100
+
+// modelPrefix selects a strategy through the model name, e.g. "mock-lorem" or "mock-markov-long".
+const modelPrefix = "mock-"
+
+// Request is what a generator builds the reply from.
+type Request struct {
+	Prompt string
+	Model  string
+	// Text is the fixed reply or the template.
+	Text string
+	// Length is the number of words to generate.
+	Length int
+	// Seed seeds the random generators.
+	Seed int64
+	// Index is the index of the choice, when several are requested.
+	Index int
+}
+
+// Generator generates the reply of a chat request.
+type Generator interface {
+	Generate(request Request) string
+}
+
+var generators = map[string]Generator{
+	config.GeneratorEcho:     echo{},
+	config.GeneratorFixed:    fixed{},
+	config.GeneratorLorem:    lorem{},
+	config.GeneratorReverse:  reverse{},
+	config.GeneratorTemplate: replyTemplate{},
+	config.GeneratorMarkov:   &markov{},
+}
+
+// Generate generates the reply to the prompt with the configured strategy.
+func Generate(cfg *config.GeneratorConfig, prompt, model string) string {
+	return GenerateChoice(cfg, prompt, model, 0)
+}
+
+// GenerateChoice generates the reply of a choice. The random strategies are seeded differently for
+// every choice, and the template is given the .Index of the choice.
+func GenerateChoice(cfg *config.GeneratorConfig, prompt, model string, index int) string {
+	generator, ok := generators[cfg.Strategy]
+	if !ok {
+		generator = echo{}
+	}
+	request := Request{Prompt: prompt, Model: model, Text: cfg.Text, Length: cfg.Length, Seed: cfg.Seed, Index: index}
+	if request.Length <= 0 {
+		request.Length = defaultLength
+	}
+	if request.Seed == 0 {
+		hash := fnv.New64a()
+		hash.Write([]byte(prompt))
+		request.Seed = int64(hash.Sum64())
+	}
+	request.Seed += int64(index)
+	return generator.Generate(request)
+}
+
+// ForModel returns the generator selected by a "mock-<strategy>" model name, or nil if the model
+// does not name one.
+func ForModel(model string) *config.GeneratorConfig {
+	if !strings.HasPrefix(model, modelPrefix) {
+		return nil
+	}
+	strategy := strings.SplitN(strings.TrimPrefix(model, modelPrefix), "-", 2)[0]
+	if _, ok := generators[strategy]; !ok {
+		return nil
+	}
+	return &config.GeneratorConfig{Strategy: strategy}
+}
+
+type echo struct{}
+
+func (echo) Generate(request Request) string {
+	return request.Prompt
+}
+
+type fixed struct{}
+
+func (fixed) Generate(request Request) string {
+	return request.Text
+}
+
+type reverse struct{}
+
+func (reverse) Generate(request Request) string {
+	runes := []rune(request.Prompt)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+type replyTemplate struct{}
+
+func (replyTemplate) Generate(request Request) string {
+	tmpl, err := template.New("reply").Parse(request.Text)
+	if err != nil {
+		return err.Error()
+	}
+	var reply strings.Builder
+	if err := tmpl.Execute(&reply, request); err != nil {
+		return err.Error()
+	}
+	return reply.String()
+}
+
+var loremWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod
+	tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation
+	ullamco laboris nisi aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate velit
+	esse cillum fugiat nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa qui
+	officia deserunt mollit anim id est laborum`)
+
+// lorem generates sentences of 6 to 14 random lorem ipsum words.
+type lorem struct{}
+
+func (lorem) Generate(request Request) string {
+	random := rand.New(rand.NewSource(request.Seed))
+	words := make([]string, 0, request.Length)
+	sentence := 0
+	for len(words) < request.Length {
+		if sentence == 0 {
+			sentence = 6 + random.Intn(9)
+		}
+		word := loremWords[random.Intn(len(loremWords))]
+		if len(words) == 0 || strings.HasSuffix(words[len(words)-1], ".") {
+			word = capitalize(word)
+		}
+		sentence--
+		if sentence == 0 || len(words) == request.Length-1 {
+			word += "."
+		} else if random.Intn(10) == 0 {
+			word += ","
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+//go:embed corpus.txt
+var corpus string
+
+// markov generates text with an order-2 word chain trained on the embedded corpus.
+type markov struct {
+	once sync.Once
+	// chain maps two consecutive words to the words that follow them in the corpus.
+	chain map[[2]string][]string
+	// starts are the first two words of the corpus sentences.
+	starts [][2]string
+}
+
+func (m *markov) train() {
+	m.chain = make(map[[2]string][]string)
+	for _, line := range strings.Split(corpus, "\n") {
+		words := strings.Fields(line)
+		if len(words) < 3 {
+			continue
+		}
+		m.starts = append(m.starts, [2]string{words[0], words[1]})
+		for i := 2; i < len(words); i++ {
+			key := [2]string{words[i-2], words[i-1]}
+			m.chain[key] = append(m.chain[key], words[i])
+		}
+	}
+}
+
+func (m *markov) Generate(request Request) string {
+	m.once.Do(m.train)
+	random := rand.New(rand.NewSource(request.Seed))
+	words := make([]string, 0, request.Length+2)
+	var state [2]string
+	for len(words) < request.Length {
+		next := m.chain[state]
+		if len(next) == 0 {
+			// The sentence ended: start a new one.
+			state = m.starts[random.Intn(len(m.starts))]
+			words = append(words, state[0], state[1])
+			continue
+		}
+		word := next[random.Intn(len(next))]
+		words = append(words, word)
+		state = [2]string{state[1], word}
+	}
+	words = words[:request.Length]
+	if last := words[len(words)-1]; !strings.HasSuffix(last, ".") {
+		words[len(words)-1] = strings.TrimRight(last, ",") + "."
+	}
+	return strings.Join(words, " ")
+}
+
+func capitalize(word string) string {
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
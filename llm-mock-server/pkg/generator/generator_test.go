@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"llm-mock-server/pkg/config"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.GeneratorConfig
+		expected string
+	}{
+		{"echo", config.GeneratorConfig{Strategy: config.GeneratorEcho}, "你好, world"},
+		{"fixed", config.GeneratorConfig{Strategy: config.GeneratorFixed, Text: "canned"}, "canned"},
+		{"reverse", config.GeneratorConfig{Strategy: config.GeneratorReverse}, "dlrow ,好你"},
+		{"template", config.GeneratorConfig{Strategy: config.GeneratorTemplate, Text: "{{.Model}}: {{.Prompt}}"}, "gpt-4o: 你好, world"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Generate(&test.cfg, "你好, world", "gpt-4o"); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRandomGenerators(t *testing.T) {
+	for _, strategy := range []string{config.GeneratorLorem, config.GeneratorMarkov} {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := &config.GeneratorConfig{Strategy: strategy, Length: 250}
+			reply := Generate(cfg, "prompt", "m")
+			if words := strings.Fields(reply); len(words) != 250 || !strings.HasSuffix(reply, ".") {
+				t.Errorf("Expected 250 words ending a sentence, got %d words: %q", len(words), reply)
+			}
+			if again := Generate(cfg, "prompt", "m"); again != reply {
+				t.Error("Expected the same prompt to get the same reply")
+			}
+			if other := Generate(cfg, "other prompt", "m"); other == reply {
+				t.Error("Expected another prompt to get another reply")
+			}
+			if choice := GenerateChoice(cfg, "prompt", "m", 1); choice == reply {
+				t.Error("Expected another choice to get another reply")
+			}
+			cfg.Seed = 42
+			if Generate(cfg, "prompt", "m") != Generate(cfg, "other prompt", "m") {
+				t.Error("Expected a seed to make the reply independent of the prompt")
+			}
+		})
+	}
+}
+
+func TestForModel(t *testing.T) {
+	if cfg := ForModel("mock-markov-long"); cfg == nil || cfg.Strategy != config.GeneratorMarkov {
+		t.Errorf("Expected mock-markov-long to select markov, got %+v", cfg)
+	}
+	for _, model := range []string{"gpt-4o", "mock-unknown"} {
+		if cfg := ForModel(model); cfg != nil {
+			t.Errorf("Expected %s not to select a generator, got %+v", model, cfg)
+		}
+	}
+}
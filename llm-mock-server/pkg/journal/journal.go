@@ -0,0 +1,147 @@
+package journal
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-mock-server/pkg/config"
+)
+
+const (
+	// ProviderKey is the gin context key under which the chat handlers record the matched provider.
+	ProviderKey = "journalProvider"
+	// StubKey is the gin context key under which the chat handlers record the id of the served stub.
+	StubKey = "journalStub"
+
+	// DefaultSize is the number of requests kept when no size is configured.
+	DefaultSize = 1000
+	// MaxResponseBody is the number of response body bytes kept in the response summary.
+	MaxResponseBody = 4096
+)
+
+// Entry is a single request captured by the journal.
+type Entry struct {
+	Id       string      `json:"id"`
+	Time     time.Time   `json:"time"`
+	Method   string      `json:"method"`
+	Host     string      `json:"host"`
+	Path     string      `json:"path"`
+	Query    string      `json:"query,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     string      `json:"body"`
+	Provider string      `json:"provider,omitempty"`
+	Stub     string      `json:"stub,omitempty"`
+	Response Response    `json:"response"`
+}
+
+// Response summarizes what the mock wrote back.
+type Response struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	Size        int             `json:"size"`
+	Body        string          `json:"body,omitempty"`
+	Truncated   bool            `json:"truncated,omitempty"`
+	Latency     config.Duration `json:"latency"`
+}
+
+// Filter selects journal entries. Empty fields match anything.
+type Filter struct {
+	Provider string
+	Path     string
+	// Headers must all be present on the request with exactly the given values.
+	Headers map[string]string
+}
+
+func (f Filter) Matches(entry *Entry) bool {
+	if f.Provider != "" && !strings.EqualFold(f.Provider, entry.Provider) {
+		return false
+	}
+	if f.Path != "" && f.Path != entry.Path {
+		return false
+	}
+	for name, value := range f.Headers {
+		if entry.Headers.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Journal is a bounded in-memory log of the requests served by the mock. Once full, the oldest
+// entries are dropped.
+type Journal struct {
+	mutex   sync.RWMutex
+	size    int
+	seq     int64
+	entries []*Entry
+}
+
+// DefaultJournal is the journal shared by the recording middleware and the admin API.
+var DefaultJournal = New(DefaultSize)
+
+func New(size int) *Journal {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Journal{size: size}
+}
+
+// Resize changes the number of entries kept, dropping the oldest ones if needed.
+func (j *Journal) Resize(size int) {
+	if size <= 0 {
+		return
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.size = size
+	j.trim()
+}
+
+func (j *Journal) Record(entry *Entry) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.seq++
+	entry.Id = strconv.FormatInt(j.seq, 10)
+	j.entries = append(j.entries, entry)
+	j.trim()
+}
+
+func (j *Journal) trim() {
+	if overflow := len(j.entries) - j.size; overflow > 0 {
+		j.entries = append([]*Entry{}, j.entries[overflow:]...)
+	}
+}
+
+// Find returns the entries accepted by the filter, oldest first.
+func (j *Journal) Find(filter Filter) []*Entry {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	entries := make([]*Entry, 0)
+	for _, entry := range j.entries {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (j *Journal) Get(id string) *Entry {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	for _, entry := range j.entries {
+		if entry.Id == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Reset drops all entries.
+func (j *Journal) Reset() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.entries = nil
+}
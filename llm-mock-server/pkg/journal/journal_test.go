@@ -0,0 +1,131 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestJournalBounded(t *testing.T) {
+	j := New(2)
+	for _, path := range []string{"/a", "/b", "/c"} {
+		j.Record(&Entry{Path: path})
+	}
+	entries := j.Find(Filter{})
+	if len(entries) != 2 || entries[0].Path != "/b" || entries[1].Path != "/c" {
+		t.Errorf("Expected the two newest entries to be kept, got %+v", entries)
+	}
+	if j.Get("1") != nil {
+		t.Error("Expected the oldest entry to be dropped")
+	}
+}
+
+func TestFind(t *testing.T) {
+	j := New(10)
+	j.Record(&Entry{Path: "/v1/messages", Provider: "claude", Headers: http.Header{"X-Api-Key": []string{"k1"}}})
+	j.Record(&Entry{Path: "/v1/messages", Provider: "claude", Headers: http.Header{"X-Api-Key": []string{"k2"}}})
+	j.Record(&Entry{Path: "/v1/chat/completions", Provider: "openai", Headers: http.Header{}})
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{name: "all", filter: Filter{}, expected: []string{"1", "2", "3"}},
+		{name: "provider ignores case", filter: Filter{Provider: "Claude"}, expected: []string{"1", "2"}},
+		{name: "path", filter: Filter{Path: "/v1/chat/completions"}, expected: []string{"3"}},
+		{name: "header", filter: Filter{Provider: "claude", Headers: map[string]string{"x-api-key": "k2"}}, expected: []string{"2"}},
+		{name: "no match", filter: Filter{Provider: "gemini"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, 0)
+			for _, entry := range j.Find(tt.filter) {
+				ids = append(ids, entry.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected entries %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var document interface{}
+	_ = json.Unmarshal([]byte(`{"messages":[{"role":"system"},{"role":"user","content":[{"text":"hi"}]}],"n":1}`), &document)
+
+	tests := []struct {
+		path     string
+		expected interface{}
+		found    bool
+	}{
+		{path: "$.messages[0].role", expected: "system", found: true},
+		{path: "messages[-1].content[0].text", expected: "hi", found: true},
+		{path: "$.n", expected: float64(1), found: true},
+		{path: "$.messages[2]", found: false},
+		{path: "$.missing.key", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, found := Lookup(document, tt.path)
+			if found != tt.found || (found && value != tt.expected) {
+				t.Errorf("Expected (%v, %t), got (%v, %t)", tt.expected, tt.found, value, found)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	j := New(10)
+	claude := http.Header{"X-Api-Key": []string{"k1"}}
+	j.Record(&Entry{Method: "POST", Path: "/v1/messages", Provider: "claude", Headers: claude, Body: `{"system":"be nice","model":"claude-3"}`})
+	j.Record(&Entry{Method: "POST", Path: "/v1/messages", Provider: "claude", Headers: claude, Body: `{"model":"claude-3"}`})
+	j.Record(&Entry{Method: "POST", Path: "/v1/chat/completions", Provider: "openai", Headers: http.Header{}, Body: `{}`})
+
+	count := func(n int) *int { return &n }
+	exists := true
+	tests := []struct {
+		name         string
+		verification Verification
+		passed       bool
+		matched      int
+		nearMisses   int
+	}{
+		{
+			name:         "count by provider",
+			verification: Verification{Request: Pattern{Provider: "claude"}, Count: count(2)},
+			passed:       true,
+			matched:      2,
+		},
+		{
+			name: "body and header predicates",
+			verification: Verification{Request: Pattern{
+				Headers:      map[string]string{"x-api-key": "k1"},
+				BodyPatterns: []BodyPattern{{Path: "$.system", Exists: &exists}, {Path: "model", Matches: "^claude"}},
+			}, Count: count(1)},
+			passed:  true,
+			matched: 1,
+		},
+		{
+			name:         "range not met reports near misses",
+			verification: Verification{Request: Pattern{Path: "/v1/messages", BodyPatterns: []BodyPattern{{Path: "system", Equals: json.RawMessage(`"be mean"`)}}}, AtLeast: count(1), AtMost: count(3)},
+			passed:       false,
+			nearMisses:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verification.Validate(); err != nil {
+				t.Fatalf("Failed to validate verification: %v", err)
+			}
+			result := j.Verify(&tt.verification)
+			if result.Passed != tt.passed || len(result.Matched) != tt.matched || len(result.NearMisses) != tt.nearMisses {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		})
+	}
+}
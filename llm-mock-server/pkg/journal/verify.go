@@ -0,0 +1,268 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxNearMisses is the number of closest non-matching requests reported by a failed verification.
+const maxNearMisses = 3
+
+// Pattern describes the requests a verification counts. Empty fields match anything.
+type Pattern struct {
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	// Headers must all be present on the request with exactly the given values.
+	Headers map[string]string `json:"headers,omitempty"`
+	// BodyPatterns are predicates evaluated against the JSON request body.
+	BodyPatterns []BodyPattern `json:"bodyPatterns,omitempty"`
+}
+
+// BodyPattern is a predicate on the value found at a JSON path of the request body, such as
+// "$.messages[0].role" or "toolConfig.tools[-1].toolSpec.name". Exactly one predicate must be set.
+type BodyPattern struct {
+	Path     string          `json:"path"`
+	Equals   json.RawMessage `json:"equals,omitempty"`
+	Contains string          `json:"contains,omitempty"`
+	Matches  string          `json:"matches,omitempty"`
+	Exists   *bool           `json:"exists,omitempty"`
+
+	matches *regexp.Regexp
+}
+
+// Verification is a pattern plus the number of matching requests expected. When no count is
+// given at all, at least one matching request is expected.
+type Verification struct {
+	Request Pattern `json:"request"`
+	Count   *int    `json:"count,omitempty"`
+	AtLeast *int    `json:"atLeast,omitempty"`
+	AtMost  *int    `json:"atMost,omitempty"`
+}
+
+// Result is the outcome of a verification.
+type Result struct {
+	Passed     bool       `json:"passed"`
+	Count      int        `json:"count"`
+	Expected   string     `json:"expected"`
+	Matched    []string   `json:"matched"`
+	NearMisses []NearMiss `json:"nearMisses,omitempty"`
+}
+
+// NearMiss is a journal entry that failed the pattern, with the reasons it failed.
+type NearMiss struct {
+	Request    *Entry   `json:"request"`
+	Mismatches []string `json:"mismatches"`
+}
+
+// Validate checks the verification and compiles its regular expressions.
+func (v *Verification) Validate() error {
+	for i := range v.Request.BodyPatterns {
+		pattern := &v.Request.BodyPatterns[i]
+		if pattern.Path == "" {
+			return fmt.Errorf("bodyPatterns[%d]: path is required", i)
+		}
+		predicates := 0
+		for _, set := range []bool{len(pattern.Equals) > 0, pattern.Contains != "", pattern.Matches != "", pattern.Exists != nil} {
+			if set {
+				predicates++
+			}
+		}
+		if predicates != 1 {
+			return fmt.Errorf("bodyPatterns[%d]: exactly one of equals, contains, matches and exists must be set", i)
+		}
+		if pattern.Matches != "" {
+			re, err := regexp.Compile(pattern.Matches)
+			if err != nil {
+				return fmt.Errorf("bodyPatterns[%d]: invalid matches: %v", i, err)
+			}
+			pattern.matches = re
+		}
+	}
+	if v.Count != nil && (v.AtLeast != nil || v.AtMost != nil) {
+		return fmt.Errorf("count cannot be combined with atLeast/atMost")
+	}
+	return nil
+}
+
+// Verify counts the journal entries matching the verification and compares it with the expectation.
+func (j *Journal) Verify(v *Verification) Result {
+	result := Result{Expected: v.expected(), Matched: make([]string, 0)}
+	var misses []NearMiss
+	for _, entry := range j.Find(Filter{}) {
+		mismatches := v.Request.mismatches(entry)
+		if len(mismatches) == 0 {
+			result.Matched = append(result.Matched, entry.Id)
+			continue
+		}
+		misses = append(misses, NearMiss{Request: entry, Mismatches: mismatches})
+	}
+	result.Count = len(result.Matched)
+	result.Passed = v.accepts(result.Count)
+	if !result.Passed {
+		// The closest requests are the ones failing the fewest criteria, most recent first.
+		for a, b := 0, len(misses)-1; a < b; a, b = a+1, b-1 {
+			misses[a], misses[b] = misses[b], misses[a]
+		}
+		sort.SliceStable(misses, func(a, b int) bool {
+			return len(misses[a].Mismatches) < len(misses[b].Mismatches)
+		})
+		if len(misses) > maxNearMisses {
+			misses = misses[:maxNearMisses]
+		}
+		result.NearMisses = misses
+	}
+	return result
+}
+
+func (v *Verification) accepts(count int) bool {
+	if v.Count != nil {
+		return count == *v.Count
+	}
+	if v.AtLeast == nil && v.AtMost == nil {
+		return count >= 1
+	}
+	return (v.AtLeast == nil || count >= *v.AtLeast) && (v.AtMost == nil || count <= *v.AtMost)
+}
+
+func (v *Verification) expected() string {
+	switch {
+	case v.Count != nil:
+		return fmt.Sprintf("exactly %d", *v.Count)
+	case v.AtLeast != nil && v.AtMost != nil:
+		return fmt.Sprintf("between %d and %d", *v.AtLeast, *v.AtMost)
+	case v.AtMost != nil:
+		return fmt.Sprintf("at most %d", *v.AtMost)
+	case v.AtLeast != nil:
+		return fmt.Sprintf("at least %d", *v.AtLeast)
+	}
+	return "at least 1"
+}
+
+// mismatches returns why the entry does not match the pattern; an empty result means it matches.
+func (p *Pattern) mismatches(entry *Entry) []string {
+	var mismatches []string
+	if p.Method != "" && !strings.EqualFold(p.Method, entry.Method) {
+		mismatches = append(mismatches, fmt.Sprintf("method: expected %s, got %s", p.Method, entry.Method))
+	}
+	if p.Path != "" && p.Path != entry.Path {
+		mismatches = append(mismatches, fmt.Sprintf("path: expected %s, got %s", p.Path, entry.Path))
+	}
+	if p.Provider != "" && !strings.EqualFold(p.Provider, entry.Provider) {
+		mismatches = append(mismatches, fmt.Sprintf("provider: expected %s, got %s", p.Provider, entry.Provider))
+	}
+	for name, value := range p.Headers {
+		if actual := entry.Headers.Get(name); actual != value {
+			mismatches = append(mismatches, fmt.Sprintf("header %s: expected %q, got %q", name, value, actual))
+		}
+	}
+	if len(p.BodyPatterns) == 0 {
+		return mismatches
+	}
+	var body interface{}
+	if err := json.Unmarshal([]byte(entry.Body), &body); err != nil {
+		return append(mismatches, "body: not a JSON document")
+	}
+	for _, pattern := range p.BodyPatterns {
+		if mismatch := pattern.mismatch(body); mismatch != "" {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+func (p *BodyPattern) mismatch(body interface{}) string {
+	value, found := Lookup(body, p.Path)
+	switch {
+	case p.Exists != nil:
+		if found != *p.Exists {
+			return fmt.Sprintf("body %s: expected exists=%t", p.Path, *p.Exists)
+		}
+		return ""
+	case !found:
+		return fmt.Sprintf("body %s: not found", p.Path)
+	case len(p.Equals) > 0:
+		var expected interface{}
+		if err := json.Unmarshal(p.Equals, &expected); err != nil {
+			return fmt.Sprintf("body %s: invalid expected value: %v", p.Path, err)
+		}
+		if !reflect.DeepEqual(expected, value) {
+			return fmt.Sprintf("body %s: expected %s, got %s", p.Path, p.Equals, marshal(value))
+		}
+	case p.Contains != "":
+		if !strings.Contains(stringValue(value), p.Contains) {
+			return fmt.Sprintf("body %s: expected to contain %q, got %s", p.Path, p.Contains, marshal(value))
+		}
+	case p.matches != nil:
+		if !p.matches.MatchString(stringValue(value)) {
+			return fmt.Sprintf("body %s: expected to match %q, got %s", p.Path, p.Matches, marshal(value))
+		}
+	}
+	return ""
+}
+
+// Lookup returns the value at a JSON path made of dotted keys and [index] segments. The leading
+// "$" is optional and negative indexes count from the end of an array.
+func Lookup(document interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	current := document
+	for path != "" {
+		var segment string
+		if strings.HasPrefix(path, "[") {
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, false
+			}
+			segment, path = path[:end+1], path[end+1:]
+		} else {
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segment, path = path[:end], path[end:]
+		}
+		path = strings.TrimPrefix(path, ".")
+
+		if strings.HasPrefix(segment, "[") {
+			index, err := strconv.Atoi(segment[1 : len(segment)-1])
+			array, ok := current.([]interface{})
+			if err != nil || !ok {
+				return nil, false
+			}
+			if index < 0 {
+				index += len(array)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, false
+			}
+			current = array[index]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// stringValue returns strings as-is and any other JSON value in its serialized form.
+func stringValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return marshal(value)
+}
+
+func marshal(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
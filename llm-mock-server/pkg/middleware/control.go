@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/fault"
+)
+
+// ScenarioKey is the gin context key of the scenario requested through the control headers.
+const ScenarioKey = "mockScenario"
+
+// Control headers. Unlike the __mock__ prompt directives they keep the prompt untouched, so they
+// also work behind prompt-transforming gateway plugins.
+const (
+	HeaderStatus     = "X-Mock-Status"
+	HeaderDelay      = "X-Mock-Delay"
+	HeaderChunkDelay = "X-Mock-Chunk-Delay"
+	HeaderResponse   = "X-Mock-Response"
+	HeaderScenario   = "X-Mock-Scenario"
+)
+
+// MockControl reads the control headers into the scenario of the request. X-Mock-Scenario selects
+// a scenario of the config, which the other headers override. The delay is waited right away; the
+// rest of the scenario is applied by the provider mocks, which render errors in their native shape.
+func MockControl(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scenario, err := requestScenario(cfg, c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if scenario == nil {
+			c.Next()
+			return
+		}
+		if delay := scenario.Delay.Duration(); delay > 0 {
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(delay):
+			}
+			scenario.Delay = 0
+		}
+		c.Set(ScenarioKey, scenario)
+		c.Next()
+	}
+}
+
+// GetScenario returns the scenario requested through the control headers, or nil if there is none.
+func GetScenario(c *gin.Context) *config.Scenario {
+	if value, exists := c.Get(ScenarioKey); exists {
+		if scenario, ok := value.(*config.Scenario); ok {
+			return scenario
+		}
+	}
+	return nil
+}
+
+func requestScenario(cfg *config.Config, request *http.Request) (*config.Scenario, error) {
+	var scenario *config.Scenario
+	if name := request.Header.Get(HeaderScenario); name != "" {
+		if scenario = cfg.Scenario(name); scenario == nil {
+			return nil, fmt.Errorf("unknown %s %q", HeaderScenario, name)
+		}
+	}
+
+	headers := &config.Scenario{Reply: request.Header.Get(HeaderResponse)}
+	if value := request.Header.Get(HeaderStatus); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", HeaderStatus, value)
+		}
+		headers.Status = status
+	}
+	for name, target := range map[string]*config.Duration{HeaderDelay: &headers.Delay, HeaderChunkDelay: &headers.ChunkDelay} {
+		if value := request.Header.Get(name); value != "" {
+			delay, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %v", name, value, err)
+			}
+			*target = config.Duration(delay)
+		}
+	}
+	faults, err := fault.FromRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	headers.Faults = faults
+	if err := headers.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid control headers: %v", err)
+	}
+
+	if *headers == (config.Scenario{}) {
+		headers = nil
+	}
+	merged := scenario.Merge(headers)
+	if merged == nil {
+		return nil, nil
+	}
+	// Copy the scenario so that the delay can be cleared without modifying the config.
+	copied := *merged
+	return &copied, nil
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/journal"
+)
+
+// journalWriter tees the first bytes of the response body into a buffer for the journal.
+type journalWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	size int
+}
+
+func (w *journalWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *journalWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *journalWriter) capture(data []byte) {
+	w.size += len(data)
+	if remaining := journal.MaxResponseBody - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
+
+// Journal records every request, except the ones to the admin API below skipPrefix, into the journal.
+func Journal(j *journal.Journal, skipPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, skipPrefix) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		entry := &journal.Entry{
+			Time:    start,
+			Method:  c.Request.Method,
+			Host:    c.Request.Host,
+			Path:    c.Request.URL.Path,
+			Query:   c.Request.URL.RawQuery,
+			Headers: c.Request.Header.Clone(),
+			Body:    string(body),
+		}
+		writer := &journalWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		entry.Provider = c.GetString(journal.ProviderKey)
+		entry.Stub = c.GetString(journal.StubKey)
+		entry.Response = journal.Response{
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Size:        writer.size,
+			Body:        writer.body.String(),
+			Truncated:   writer.size > writer.body.Len(),
+			Latency:     config.Duration(time.Since(start)),
+		}
+		j.Record(entry)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"llm-mock-server/pkg/cmd/options"
 	"llm-mock-server/pkg/log"
 )
 
@@ -132,7 +131,7 @@ func CustomRecoveryWithZap(logger *zap.Logger, stack bool, recovery gin.Recovery
 	}
 }
 
-func StartLogger(g *gin.Engine, option *options.Option) {
+func StartLogger(g *gin.Engine) {
 	logger := log.Logger()
 	g.Use(Ginzap(logger, &Config{}))
 	g.Use(RecoveryWithZap(logger, true))
@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"fmt"
+	"net/http"
+
+	"llm-mock-server/pkg/apikey"
+	"llm-mock-server/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyErrorRenderer is implemented by providers whose real API answers a rejected key with more
+// than their generic error shape, such as a dedicated error code.
+type apiKeyErrorRenderer interface {
+	sendAPIKeyError(ctx *gin.Context, model, rejection string)
+}
+
+// sendAPIKeyError rejects a request whose API key was rejected by the key registry with the
+// provider's native 401, 403 or 429.
+func sendAPIKeyError(ctx *gin.Context, handler requestHandler, model, rejection string) {
+	if renderer, ok := handler.(apiKeyErrorRenderer); ok {
+		renderer.sendAPIKeyError(ctx, model, rejection)
+		return
+	}
+	statusCode, message := apiKeyError(model, rejection)
+	sendMockError(ctx, handler, statusCode, message)
+}
+
+// apiKeyError returns the status code and the message of a rejection of the key registry.
+func apiKeyError(model, rejection string) (int, string) {
+	switch rejection {
+	case config.KeyExpired:
+		return http.StatusUnauthorized, "The API key has expired, please renew it."
+	case config.KeyQuotaExhausted:
+		return http.StatusTooManyRequests, "You exceeded your current quota, please check your plan and billing details."
+	case apikey.ModelNotAllowed:
+		return http.StatusForbidden, fmt.Sprintf("The API key does not have access to the model `%s`.", model)
+	default:
+		return http.StatusUnauthorized, "Invalid API key provided."
+	}
+}
+
+func (p *openAiProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	statusCode, message := apiKeyError(model, rejection)
+	errType, code := "invalid_request_error", "invalid_api_key"
+	switch rejection {
+	case config.KeyQuotaExhausted:
+		errType, code = "insufficient_quota", "insufficient_quota"
+		message = "You exceeded your current quota, please check your plan and billing details. For more information on this error, read the docs: https://platform.openai.com/docs/guides/error-codes/api-errors."
+	case apikey.ModelNotAllowed:
+		code = "model_not_found"
+		message = fmt.Sprintf("Project `proj_llm_mock` does not have access to model `%s`", model)
+	default:
+		message = "Incorrect API key provided. You can find your API key at https://platform.openai.com/account/api-keys."
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errType,
+			"param":   nil,
+			"code":    code,
+		},
+	})
+}
+
+func (p *claudeProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	statusCode, message := apiKeyError(model, rejection)
+	if statusCode == http.StatusUnauthorized {
+		message = "invalid x-api-key"
+	}
+	claudeError(ctx, statusCode, claudeErrorType(statusCode), message)
+}
+
+// googleAPIKeyError is the error of the Gemini and Vertex APIs for a rejected key. An invalid or
+// expired key is a 400 INVALID_ARGUMENT whose ErrorInfo gives the API_KEY_INVALID reason.
+func googleAPIKeyError(ctx *gin.Context, service, model, rejection string) {
+	statusCode, message := apiKeyError(model, rejection)
+	switch rejection {
+	case config.KeyQuotaExhausted, apikey.ModelNotAllowed:
+		ctx.JSON(statusCode, gin.H{
+			"error": gin.H{
+				"code":    statusCode,
+				"message": message,
+				"status":  geminiErrorStatus(statusCode),
+			},
+		})
+		return
+	case config.KeyExpired:
+		message = "API key expired. Please renew the API key."
+	default:
+		message = "API key not valid. Please pass a valid API key."
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    http.StatusBadRequest,
+			"message": message,
+			"status":  geminiErrorStatus(http.StatusBadRequest),
+			"details": []gin.H{
+				{
+					"@type":    "type.googleapis.com/google.rpc.ErrorInfo",
+					"reason":   "API_KEY_INVALID",
+					"domain":   "googleapis.com",
+					"metadata": gin.H{"service": service},
+				},
+				{
+					"@type":   "type.googleapis.com/google.rpc.LocalizedMessage",
+					"locale":  "en-US",
+					"message": message,
+				},
+			},
+		},
+	})
+}
+
+func (p *geminiProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	googleAPIKeyError(ctx, geminiDomain, model, rejection)
+}
+
+func (p *vertexProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	googleAPIKeyError(ctx, vertexDomain, model, rejection)
+}
+
+func (p *qwenProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	statusCode, message := apiKeyError(model, rejection)
+	if statusCode == http.StatusUnauthorized {
+		message = "Invalid API-key provided."
+	}
+	p.sendErrorResponse(ctx, statusCode, qwenErrorCode(statusCode), message)
+}
+
+// sendAPIKeyError answers a rejected key the way AWS rejects credentials: an unknown or expired
+// key is a 403 exception of its own rather than an AccessDeniedException.
+func (p *bedrockProvider) sendAPIKeyError(ctx *gin.Context, model, rejection string) {
+	statusCode, message := apiKeyError(model, rejection)
+	exception := bedrockExceptionType(statusCode)
+	switch rejection {
+	case config.KeyQuotaExhausted:
+		message = "Too many tokens, please wait before trying again."
+	case apikey.ModelNotAllowed:
+		message = "You don't have access to the model with the specified model ID."
+	case config.KeyExpired:
+		statusCode, exception, message = http.StatusForbidden, "ExpiredTokenException", "The security token included in the request is expired"
+	default:
+		statusCode, exception, message = http.StatusForbidden, "UnrecognizedClientException", "The security token included in the request is invalid."
+	}
+	ctx.Header("x-amzn-ErrorType", exception)
+	p.sendErrorResponse(ctx, statusCode, message)
+}
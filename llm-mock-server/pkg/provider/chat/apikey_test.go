@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"llm-mock-server/pkg/apikey"
+	"llm-mock-server/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSendAPIKeyError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		handler   requestHandler
+		rejection string
+		status    int
+		body      string
+		errorType string
+	}{
+		{name: "gemini invalid", handler: &geminiProvider{}, rejection: config.KeyInvalid, status: http.StatusBadRequest, body: `"reason":"API_KEY_INVALID"`},
+		{name: "gemini expired", handler: &geminiProvider{}, rejection: config.KeyExpired, status: http.StatusBadRequest, body: `"message":"API key expired. Please renew the API key."`},
+		{name: "gemini quota", handler: &geminiProvider{}, rejection: config.KeyQuotaExhausted, status: http.StatusTooManyRequests, body: `"status":"RESOURCE_EXHAUSTED"`},
+		{name: "vertex invalid", handler: &vertexProvider{}, rejection: config.KeyInvalid, status: http.StatusBadRequest, body: `"service":"aiplatform.googleapis.com"`},
+		{name: "qwen invalid", handler: &qwenProvider{}, rejection: config.KeyInvalid, status: http.StatusUnauthorized, body: `"code":"InvalidApiKey"`},
+		{name: "qwen model", handler: &qwenProvider{}, rejection: apikey.ModelNotAllowed, status: http.StatusForbidden, body: `"code":"AccessDenied"`},
+		{name: "bedrock invalid", handler: &bedrockProvider{}, rejection: config.KeyInvalid, status: http.StatusForbidden, body: "security token included in the request is invalid", errorType: "UnrecognizedClientException"},
+		{name: "bedrock expired", handler: &bedrockProvider{}, rejection: config.KeyExpired, status: http.StatusForbidden, body: "is expired", errorType: "ExpiredTokenException"},
+		{name: "bedrock quota", handler: &bedrockProvider{}, rejection: config.KeyQuotaExhausted, status: http.StatusTooManyRequests, errorType: "ThrottlingException"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			sendAPIKeyError(ctx, tt.handler, "mock-model", tt.rejection)
+			if recorder.Code != tt.status || !strings.Contains(recorder.Body.String(), tt.body) {
+				t.Errorf("Expected %d with %s, got %d %s", tt.status, tt.body, recorder.Code, recorder.Body.String())
+			}
+			if errorType := recorder.Header().Get("x-amzn-ErrorType"); errorType != tt.errorType {
+				t.Errorf("Expected the error type %q, got %q", tt.errorType, errorType)
+			}
+		})
+	}
+}
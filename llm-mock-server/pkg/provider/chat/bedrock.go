@@ -17,13 +17,21 @@ import (
 const (
 	// ai-proxy rewrites Bedrock requests to bedrock-runtime.{region}.amazonaws.com
 	// (or bedrock-mantle.{region}.api.aws). Match on the stable fragments.
-	bedrockHostFragment      = "bedrock"
-	bedrockDomainFragment    = "amazonaws.com"
-	bedrockConversePath      = "/converse"
-	bedrockConverseStream    = "/converse-stream"
-	bedrockStopReasonEndTurn = "end_turn"
+	bedrockHostFragment   = "bedrock"
+	bedrockDomainFragment = "amazonaws.com"
+	bedrockConversePath   = "/converse"
+	bedrockConverseStream = "/converse-stream"
 )
 
+// bedrockFinishReasons are the stopReason values of the real Bedrock Converse API.
+var bedrockFinishReasons = map[string]string{
+	finishStop:          "end_turn",
+	finishLength:        "max_tokens",
+	finishToolCalls:     "tool_use",
+	finishContentFilter: "content_filtered",
+	finishStopSequence:  "stop_sequence",
+}
+
 type bedrockProvider struct{}
 
 func (p *bedrockProvider) ShouldHandleRequest(ctx *gin.Context) bool {
@@ -60,12 +68,12 @@ func (p *bedrockProvider) HandleChatCompletions(ctx *gin.Context) {
 		return
 	}
 
-	content := p.generateResponse(&bedrockRequest)
+	choice := p.generateResponse(ctx, &bedrockRequest)
 
 	if isStreaming {
-		p.handleStreamResponse(ctx, content)
+		p.handleStreamResponse(ctx, choice)
 	} else {
-		p.handleNonStreamResponse(ctx, content)
+		p.handleNonStreamResponse(ctx, choice)
 	}
 }
 
@@ -73,54 +81,114 @@ func (p *bedrockProvider) validateRequest(req *bedrockConverseRequest) error {
 	if len(req.Messages) == 0 {
 		return fmt.Errorf("messages are required")
 	}
+	usesTools := false
 	for i, msg := range req.Messages {
 		if len(msg.Content) == 0 {
 			return fmt.Errorf("message %d: content is required", i)
 		}
 		for j, block := range msg.Content {
-			if block.Text == "" {
-				return fmt.Errorf("message %d, content %d: text is required", i, j)
+			if err := block.validate(); err != nil {
+				return fmt.Errorf("message %d, content %d: %v", i, j, err)
+			}
+			if block.ToolUse != nil || block.ToolResult != nil {
+				usesTools = true
+			}
+			// Like the real API, a tool result must answer a toolUse of the previous turn.
+			if block.ToolResult != nil && (i == 0 || !req.Messages[i-1].hasToolUse(block.ToolResult.ToolUseId)) {
+				return fmt.Errorf("message %d, content %d: toolResult %q does not match a toolUse of the previous turn", i, j, block.ToolResult.ToolUseId)
+			}
+		}
+	}
+	if usesTools && req.ToolConfig == nil {
+		return fmt.Errorf("toolConfig must be defined when using toolUse and toolResult content blocks")
+	}
+	if req.ToolConfig != nil {
+		for i, tool := range req.ToolConfig.Tools {
+			if tool.ToolSpec != nil && tool.ToolSpec.Name == "" {
+				return fmt.Errorf("toolConfig.tools %d: toolSpec.name is required", i)
 			}
 		}
 	}
 	return nil
 }
 
-func (p *bedrockProvider) generateResponse(req *bedrockConverseRequest) string {
+func (p *bedrockProvider) generateResponse(ctx *gin.Context, req *bedrockConverseRequest) mockChoice {
+	lastMsg := req.Messages[len(req.Messages)-1]
+	if calls := mockToolCalls(req.mockTools(), req.toolUse(), lastMsg.text(), lastMsg.hasToolResult()); len(calls) > 0 {
+		return toolChoices(ctx, 1, calls)[0]
+	}
+	if response, ok := configuredResponse(ctx); ok {
+		return currentChoice(ctx, response)
+	}
 	// Mirror the gemini/vertex mocks so the response is identifiable as the
 	// Bedrock simulation.
 	content := "This is a mock response from Bedrock provider. "
-	if len(req.Messages) > 0 {
-		lastMsg := req.Messages[len(req.Messages)-1]
-		if len(lastMsg.Content) > 0 {
-			runes := []rune(lastMsg.Content[len(lastMsg.Content)-1].Text)
-			if len(runes) > 50 {
-				content += "You said: " + string(runes[:50]) + "..."
-			} else {
-				content += "You said: " + string(runes)
-			}
-		}
+	runes := []rune(stripMockDirective(lastMsg.text()))
+	if len(runes) > 50 {
+		content += "You said: " + string(runes[:50]) + "..."
+	} else {
+		content += "You said: " + string(runes)
 	}
-	return content
+	return currentChoice(ctx, truncateResponse(ctx, content))
 }
 
-func (p *bedrockProvider) handleNonStreamResponse(ctx *gin.Context, response string) {
-	// Schema matches Bedrock Converse (output.message.content[].text / stopReason /
+// bedrockAdditionalFields returns the model-specific fields of a Converse response: the matched
+// stop sequence, which Bedrock passes through from the model, or nil.
+func bedrockAdditionalFields(ctx *gin.Context) gin.H {
+	if stop := stopSequence(ctx); stop != nil {
+		return gin.H{"stop_sequence": stop}
+	}
+	return nil
+}
+
+// bedrockBlocks returns the content blocks of the reply: its text, or a toolUse block per tool call.
+func bedrockBlocks(choice mockChoice) []bedrockContentBlock {
+	if len(choice.ToolCalls) == 0 {
+		return []bedrockContentBlock{{Text: choice.Text}}
+	}
+	blocks := make([]bedrockContentBlock, 0, len(choice.ToolCalls))
+	for _, call := range choice.ToolCalls {
+		blocks = append(blocks, bedrockContentBlock{ToolUse: &bedrockToolUseBlock{
+			ToolUseId: call.id("tooluse"),
+			Name:      call.Name,
+			Input:     call.Arguments,
+		}})
+	}
+	return blocks
+}
+
+func (p *bedrockProvider) handleNonStreamResponse(ctx *gin.Context, choice mockChoice) {
+	// Schema matches Bedrock Converse (output.message.content[] / stopReason /
 	// usage), which ai-proxy parses into an OpenAI response.
 	bedrockResponse := bedrockConverseResponse{
-		Metrics:    bedrockConverseMetrics{LatencyMs: 100},
-		Output:     bedrockConverseOutput{Message: bedrockConverseMessage{Role: "assistant", Content: []bedrockContentBlock{{Text: response}}}},
-		StopReason: bedrockStopReasonEndTurn,
-		Usage: bedrockTokenUsage{
-			InputTokens:  completionMockUsage.PromptTokens,
-			OutputTokens: completionMockUsage.CompletionTokens,
-			TotalTokens:  completionMockUsage.TotalTokens,
-		},
+		Metrics:                       bedrockConverseMetrics{LatencyMs: 100},
+		Output:                        bedrockConverseOutput{Message: bedrockConverseMessage{Role: "assistant", Content: bedrockBlocks(choice)}},
+		StopReason:                    choice.finishReason(bedrockFinishReasons),
+		AdditionalModelResponseFields: bedrockAdditionalFields(ctx),
+		Usage:                         bedrockUsage(ctx, choice),
 	}
 	ctx.JSON(http.StatusOK, bedrockResponse)
 }
 
-func (p *bedrockProvider) handleStreamResponse(ctx *gin.Context, response string) {
+func bedrockUsage(ctx *gin.Context, choice mockChoice) bedrockTokenUsage {
+	u := mockUsage(ctx, choiceTexts([]mockChoice{choice})...)
+	return bedrockTokenUsage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+}
+
+// bedrockStreamMetadata is the payload of the metadata event that ends a Converse stream, which
+// carries the usage of the whole reply.
+func bedrockStreamMetadata(ctx *gin.Context, choice mockChoice) gin.H {
+	return gin.H{
+		"usage":   bedrockUsage(ctx, choice),
+		"metrics": bedrockConverseMetrics{LatencyMs: 100},
+	}
+}
+
+func (p *bedrockProvider) handleStreamResponse(ctx *gin.Context, choice mockChoice) {
 	// Bedrock converse-stream uses the Amazon Event Stream binary framing, not
 	// SSE/JSON. Encode each ConverseStreamEvent as an event-stream message.
 	ctx.Header("Content-Type", "application/vnd.amazon.eventstream")
@@ -128,38 +196,124 @@ func (p *bedrockProvider) handleStreamResponse(ctx *gin.Context, response string
 	ctx.Header("Connection", "keep-alive")
 	ctx.Header("Access-Control-Allow-Origin", "*")
 
-	words := strings.Fields(response)
-	flusher, ok := ctx.Writer.(http.Flusher)
+	if len(choice.ToolCalls) > 0 {
+		p.handleToolUseStreamResponse(ctx, choice)
+		return
+	}
 
-	for i, word := range words {
-		deltaPayload, _ := json.Marshal(map[string]interface{}{
+	// The text is a single content block, opened by its first delta.
+	chunks := streamChunks(ctx, choice.Text, splitWords)
+	if len(chunks) == 0 {
+		// A reply cut to nothing by a stop sequence still ends with the stop reason.
+		chunks = []string{""}
+	}
+	send := bedrockEventSender(ctx)
+	if !send("messageStart", gin.H{"role": "assistant"}) {
+		return
+	}
+	for _, chunk := range chunks {
+		if !send("contentBlockDelta", gin.H{
 			"contentBlockIndex": 0,
-			"delta":             map[string]string{"text": word + " "},
-		})
-		ctx.Writer.Write(encodeBedrockEventStreamMessage("contentBlockDelta", deltaPayload))
+			"delta":             gin.H{"text": chunk},
+		}) {
+			return
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
+		}
+	}
+	if !send("contentBlockStop", gin.H{"contentBlockIndex": 0}) {
+		return
+	}
+	// The messageStop carries the stop reason and the metadata the usage.
+	if send("messageStop", gin.H{
+		"stopReason":                    choice.finishReason(bedrockFinishReasons),
+		"additionalModelResponseFields": bedrockAdditionalFields(ctx),
+	}) {
+		send("metadata", bedrockStreamMetadata(ctx, choice))
+	}
+}
+
+// bedrockEventSender returns a function that writes an event of a Converse stream and reports
+// whether the client is still there.
+func bedrockEventSender(ctx *gin.Context) func(eventType string, payload gin.H) bool {
+	flusher, ok := ctx.Writer.(http.Flusher)
+	return func(eventType string, payload gin.H) bool {
+		data, _ := json.Marshal(payload)
+		ctx.Writer.Write(encodeBedrockEventStreamMessage(eventType, data))
 		if ok {
 			flusher.Flush()
 		}
 		select {
 		case <-ctx.Request.Context().Done():
-			return
+			return false
 		default:
+			return true
 		}
+	}
+}
 
-		// The final word is followed by a messageStop carrying the stop reason.
-		if i == len(words)-1 {
-			stopPayload, _ := json.Marshal(map[string]string{"stopReason": bedrockStopReasonEndTurn})
-			ctx.Writer.Write(encodeBedrockEventStreamMessage("messageStop", stopPayload))
-			if ok {
-				flusher.Flush()
+// handleToolUseStreamResponse streams every toolUse block as a contentBlockStart carrying its id and
+// name, contentBlockDelta.toolUse events carrying fragments of its JSON input, and a
+// contentBlockStop, followed by the messageStop with the tool_use stop reason and the metadata.
+func (p *bedrockProvider) handleToolUseStreamResponse(ctx *gin.Context, choice mockChoice) {
+	send := bedrockEventSender(ctx)
+
+	if !send("messageStart", gin.H{"role": "assistant"}) {
+		return
+	}
+	for i, call := range choice.ToolCalls {
+		if !send("contentBlockStart", gin.H{
+			"contentBlockIndex": i,
+			"start":             gin.H{"toolUse": gin.H{"toolUseId": call.id("tooluse"), "name": call.Name}},
+		}) {
+			return
+		}
+		for _, fragment := range splitArguments(call.argumentsJSON()) {
+			if !send("contentBlockDelta", gin.H{
+				"contentBlockIndex": i,
+				"delta":             gin.H{"toolUse": gin.H{"input": fragment}},
+			}) {
+				return
+			}
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 			}
 		}
-		select {
-		case <-ctx.Request.Context().Done():
+		if !send("contentBlockStop", gin.H{"contentBlockIndex": i}) {
 			return
-		case <-time.After(50 * time.Millisecond):
 		}
 	}
+	if send("messageStop", gin.H{"stopReason": choice.finishReason(bedrockFinishReasons)}) {
+		send("metadata", bedrockStreamMetadata(ctx, choice))
+	}
+}
+
+// sendMockError adds the x-amzn-ErrorType header the real Bedrock API uses to name the exception.
+func (p *bedrockProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	ctx.Header("x-amzn-ErrorType", bedrockExceptionType(statusCode))
+	p.sendErrorResponse(ctx, statusCode, message)
+}
+
+// bedrockExceptionType returns the exception the real Bedrock API pairs with the status code.
+func bedrockExceptionType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "ValidationException"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "AccessDeniedException"
+	case http.StatusNotFound:
+		return "ResourceNotFoundException"
+	case http.StatusTooManyRequests:
+		return "ThrottlingException"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailableException"
+	}
+	return "InternalServerException"
 }
 
 func (p *bedrockProvider) sendErrorResponse(ctx *gin.Context, statusCode int, message string) {
@@ -220,23 +374,75 @@ func encodeBedrockEventStreamHeaders(eventType string) []byte {
 // what ai-proxy produces after transforming an OpenAI request; the response
 // schema matches what ai-proxy parses back into an OpenAI response.
 type bedrockConverseRequest struct {
-	Messages []bedrockRequestMessage `json:"messages"`
+	Messages   []bedrockConverseMessage `json:"messages"`
+	ToolConfig *bedrockToolConfig       `json:"toolConfig,omitempty"`
+}
+
+// mockTools returns the tool specs of the request.
+func (r *bedrockConverseRequest) mockTools() []mockTool {
+	if r.ToolConfig == nil {
+		return nil
+	}
+	var tools []mockTool
+	for _, tool := range r.ToolConfig.Tools {
+		if tool.ToolSpec != nil {
+			tools = append(tools, mockTool{Name: tool.ToolSpec.Name, Parameters: tool.ToolSpec.InputSchema.Json})
+		}
+	}
+	return tools
+}
+
+// toolUse returns how the toolChoice of the request lets the model use its tools.
+func (r *bedrockConverseRequest) toolUse() toolUse {
+	if r.ToolConfig == nil || r.ToolConfig.ToolChoice == nil {
+		return toolUse{Mode: toolAuto}
+	}
+	switch choice := r.ToolConfig.ToolChoice; {
+	case choice.Tool != nil:
+		return toolUse{Mode: toolRequired, Name: choice.Tool.Name}
+	case choice.Any != nil:
+		return toolUse{Mode: toolRequired}
+	}
+	return toolUse{Mode: toolAuto}
+}
+
+type bedrockToolConfig struct {
+	Tools      []bedrockTool      `json:"tools"`
+	ToolChoice *bedrockToolChoice `json:"toolChoice,omitempty"`
+}
+
+type bedrockTool struct {
+	ToolSpec   *bedrockToolSpec `json:"toolSpec,omitempty"`
+	CachePoint json.RawMessage  `json:"cachePoint,omitempty"`
+}
+
+type bedrockToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema bedrockToolInputSchema `json:"inputSchema"`
 }
 
-type bedrockRequestMessage struct {
-	Role    string                  `json:"role"`
-	Content []bedrockRequestContent `json:"content"`
+type bedrockToolInputSchema struct {
+	Json map[string]interface{} `json:"json"`
 }
 
-type bedrockRequestContent struct {
-	Text string `json:"text"`
+// bedrockToolChoice is a union: exactly one of auto, any and tool is set.
+type bedrockToolChoice struct {
+	Auto *struct{}                  `json:"auto,omitempty"`
+	Any  *struct{}                  `json:"any,omitempty"`
+	Tool *bedrockSpecificToolChoice `json:"tool,omitempty"`
+}
+
+type bedrockSpecificToolChoice struct {
+	Name string `json:"name"`
 }
 
 type bedrockConverseResponse struct {
-	Metrics    bedrockConverseMetrics `json:"metrics"`
-	Output     bedrockConverseOutput  `json:"output"`
-	StopReason string                 `json:"stopReason"`
-	Usage      bedrockTokenUsage      `json:"usage"`
+	Metrics                       bedrockConverseMetrics `json:"metrics"`
+	Output                        bedrockConverseOutput  `json:"output"`
+	StopReason                    string                 `json:"stopReason"`
+	AdditionalModelResponseFields gin.H                  `json:"additionalModelResponseFields,omitempty"`
+	Usage                         bedrockTokenUsage      `json:"usage"`
 }
 
 type bedrockConverseMetrics struct {
@@ -252,8 +458,154 @@ type bedrockConverseMessage struct {
 	Role    string                `json:"role"`
 }
 
+// text returns the text of the message, with the tool results it carries.
+func (m bedrockConverseMessage) text() string {
+	var text strings.Builder
+	for _, block := range m.Content {
+		text.WriteString(block.Text)
+		if block.ToolResult != nil {
+			for _, content := range block.ToolResult.Content {
+				text.WriteString(content.Text)
+				if content.Json != nil {
+					data, _ := json.Marshal(content.Json)
+					text.Write(data)
+				}
+			}
+		}
+	}
+	return text.String()
+}
+
+// hasToolUse reports whether the message calls the tool with the given toolUseId.
+func (m bedrockConverseMessage) hasToolUse(toolUseId string) bool {
+	for _, block := range m.Content {
+		if block.ToolUse != nil && block.ToolUse.ToolUseId == toolUseId {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToolResult reports whether the message returns the result of a tool call.
+func (m bedrockConverseMessage) hasToolResult() bool {
+	for _, block := range m.Content {
+		if block.ToolResult != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// bedrockContentBlock is the Converse ContentBlock union: exactly one member is set.
 type bedrockContentBlock struct {
-	Text string `json:"text,omitempty"`
+	Text             string                  `json:"text,omitempty"`
+	Image            *bedrockImageBlock      `json:"image,omitempty"`
+	Document         *bedrockDocumentBlock   `json:"document,omitempty"`
+	Video            *bedrockVideoBlock      `json:"video,omitempty"`
+	ToolUse          *bedrockToolUseBlock    `json:"toolUse,omitempty"`
+	ToolResult       *bedrockToolResultBlock `json:"toolResult,omitempty"`
+	GuardContent     json.RawMessage         `json:"guardContent,omitempty"`
+	CachePoint       json.RawMessage         `json:"cachePoint,omitempty"`
+	ReasoningContent json.RawMessage         `json:"reasoningContent,omitempty"`
+}
+
+var (
+	bedrockImageFormats    = []string{"png", "jpeg", "gif", "webp"}
+	bedrockDocumentFormats = []string{"pdf", "csv", "doc", "docx", "xls", "xlsx", "html", "txt", "md"}
+	bedrockVideoFormats    = []string{"mkv", "mov", "mp4", "webm", "flv", "mpeg", "mpg", "wmv", "three_gp"}
+)
+
+// validate checks that exactly one member of the block is set, and that it is well-formed.
+func (b *bedrockContentBlock) validate() error {
+	members := 0
+	for _, set := range []bool{
+		b.Text != "", b.Image != nil, b.Document != nil, b.Video != nil, b.ToolUse != nil, b.ToolResult != nil,
+		len(b.GuardContent) > 0, len(b.CachePoint) > 0, len(b.ReasoningContent) > 0,
+	} {
+		if set {
+			members++
+		}
+	}
+	switch {
+	case members == 0:
+		return fmt.Errorf("one of text, image, document, video, toolUse, toolResult, guardContent, cachePoint or reasoningContent is required")
+	case members > 1:
+		return fmt.Errorf("only one member of a content block can be set")
+	case b.Image != nil:
+		return validateBedrockMedia("image", b.Image.Format, bedrockImageFormats, b.Image.Source)
+	case b.Document != nil:
+		if b.Document.Name == "" {
+			return fmt.Errorf("document.name is required")
+		}
+		return validateBedrockMedia("document", b.Document.Format, bedrockDocumentFormats, b.Document.Source)
+	case b.Video != nil:
+		return validateBedrockMedia("video", b.Video.Format, bedrockVideoFormats, b.Video.Source)
+	case b.ToolUse != nil:
+		if b.ToolUse.ToolUseId == "" || b.ToolUse.Name == "" {
+			return fmt.Errorf("toolUse.toolUseId and toolUse.name are required")
+		}
+	case b.ToolResult != nil:
+		if b.ToolResult.ToolUseId == "" {
+			return fmt.Errorf("toolResult.toolUseId is required")
+		}
+	}
+	return nil
+}
+
+func validateBedrockMedia(member, format string, formats []string, source bedrockMediaSource) error {
+	valid := false
+	for _, f := range formats {
+		valid = valid || f == format
+	}
+	if !valid {
+		return fmt.Errorf("%s.format must be one of %s", member, strings.Join(formats, ", "))
+	}
+	if len(source.Bytes) == 0 && source.S3Location == nil {
+		return fmt.Errorf("%s.source requires bytes or s3Location", member)
+	}
+	return nil
+}
+
+type bedrockImageBlock struct {
+	Format string             `json:"format"`
+	Source bedrockMediaSource `json:"source"`
+}
+
+type bedrockDocumentBlock struct {
+	Format string             `json:"format"`
+	Name   string             `json:"name"`
+	Source bedrockMediaSource `json:"source"`
+}
+
+type bedrockVideoBlock struct {
+	Format string             `json:"format"`
+	Source bedrockMediaSource `json:"source"`
+}
+
+// bedrockMediaSource carries the base64-encoded bytes of the media, or its S3 location.
+type bedrockMediaSource struct {
+	Bytes      []byte          `json:"bytes,omitempty"`
+	S3Location json.RawMessage `json:"s3Location,omitempty"`
+}
+
+type bedrockToolUseBlock struct {
+	ToolUseId string      `json:"toolUseId"`
+	Name      string      `json:"name"`
+	Input     interface{} `json:"input"`
+}
+
+type bedrockToolResultBlock struct {
+	ToolUseId string                     `json:"toolUseId"`
+	Content   []bedrockToolResultContent `json:"content"`
+	// Status is success or error.
+	Status string `json:"status,omitempty"`
+}
+
+type bedrockToolResultContent struct {
+	Text     string                `json:"text,omitempty"`
+	Json     interface{}           `json:"json,omitempty"`
+	Image    *bedrockImageBlock    `json:"image,omitempty"`
+	Document *bedrockDocumentBlock `json:"document,omitempty"`
 }
 
 type bedrockTokenUsage struct {
@@ -0,0 +1,167 @@
+package chat
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBedrockValidateRequest(t *testing.T) {
+	const toolConfig = `"toolConfig": {"tools": [{"toolSpec": {"name": "get_weather", "inputSchema": {"json": {}}}}]}`
+	tests := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{name: "text", body: `{"messages": [{"role": "user", "content": [{"text": "hi"}]}]}`},
+		{name: "image", body: `{"messages": [{"role": "user", "content": [{"image": {"format": "png", "source": {"bytes": "aGk="}}}]}]}`},
+		{name: "document without name", body: `{"messages": [{"role": "user", "content": [{"document": {"format": "pdf", "source": {"bytes": "aGk="}}}]}]}`, error: "document.name is required"},
+		{name: "empty block", body: `{"messages": [{"role": "user", "content": [{}]}]}`, error: "one of text"},
+		{name: "two members", body: `{"messages": [{"role": "user", "content": [{"text": "hi", "cachePoint": {"type": "default"}}]}]}`, error: "only one member"},
+		{
+			name: "tool loop",
+			body: `{"messages": [
+				{"role": "user", "content": [{"text": "weather?"}]},
+				{"role": "assistant", "content": [{"toolUse": {"toolUseId": "t1", "name": "get_weather", "input": {}}}]},
+				{"role": "user", "content": [{"toolResult": {"toolUseId": "t1", "content": [{"json": {"temp": 20}}]}}]}
+			], ` + toolConfig + `}`,
+		},
+		{
+			name:  "unmatched tool result",
+			body:  `{"messages": [{"role": "user", "content": [{"toolResult": {"toolUseId": "t1", "content": [{"text": "20"}]}}]}], ` + toolConfig + `}`,
+			error: "does not match a toolUse",
+		},
+		{
+			name:  "tool use without tool config",
+			body:  `{"messages": [{"role": "assistant", "content": [{"toolUse": {"toolUseId": "t1", "name": "get_weather", "input": {}}}]}]}`,
+			error: "toolConfig must be defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req bedrockConverseRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			err := (&bedrockProvider{}).validateRequest(&req)
+			if tt.error == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.error != "" && (err == nil || !strings.Contains(err.Error(), tt.error)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+// bedrockEvent is an event of a Converse stream, decoded from its event-stream frame.
+type bedrockEvent struct {
+	Type    string
+	Payload map[string]interface{}
+}
+
+func decodeBedrockEvents(t *testing.T, data []byte) []bedrockEvent {
+	var events []bedrockEvent
+	for len(data) > 0 {
+		total := binary.BigEndian.Uint32(data[0:4])
+		headersLen := binary.BigEndian.Uint32(data[4:8])
+		headers, payload := data[12:12+headersLen], data[12+headersLen:total-4]
+		event := bedrockEvent{}
+		for len(headers) > 0 {
+			nameLen := int(headers[0])
+			name := string(headers[1 : 1+nameLen])
+			valueLen := int(binary.BigEndian.Uint16(headers[2+nameLen : 4+nameLen]))
+			if name == ":event-type" {
+				event.Type = string(headers[4+nameLen : 4+nameLen+valueLen])
+			}
+			headers = headers[4+nameLen+valueLen:]
+		}
+		if err := json.Unmarshal(payload, &event.Payload); err != nil {
+			t.Fatalf("Invalid %s payload %s: %v", event.Type, payload, err)
+		}
+		events = append(events, event)
+		data = data[total:]
+	}
+	return events
+}
+
+// serveBedrockStream returns the events of the converse-stream reply to the request body.
+func serveBedrockStream(t *testing.T, body string) []bedrockEvent {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	SetupRoutes(server, "", nil, nil)
+	request := httptest.NewRequest(http.MethodPost, "/model/anthropic.claude-3-haiku/converse-stream", strings.NewReader(body))
+	request.Host = "bedrock-runtime.us-east-1.amazonaws.com"
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	return decodeBedrockEvents(t, recorder.Body.Bytes())
+}
+
+func TestBedrockStreamMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "text", body: `{"messages": [{"role": "user", "content": [{"text": "hi"}]}]}`},
+		{
+			name: "tool use",
+			body: `{"messages": [{"role": "user", "content": [{"text": "weather?"}]}],
+				"toolConfig": {"tools": [{"toolSpec": {"name": "get_weather", "inputSchema": {"json": {}}}}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := serveBedrockStream(t, tt.body)
+			if len(events) < 2 || events[len(events)-2].Type != "messageStop" || events[len(events)-1].Type != "metadata" {
+				t.Fatalf("Expected the stream to end with messageStop and metadata, got %v", events)
+			}
+			usage, _ := events[len(events)-1].Payload["usage"].(map[string]interface{})
+			if usage["inputTokens"] == nil || usage["outputTokens"] == nil || usage["totalTokens"] == nil {
+				t.Errorf("Expected the metadata to carry the usage, got %v", events[len(events)-1].Payload)
+			}
+		})
+	}
+}
+
+func TestBedrockStreamEmptyReply(t *testing.T) {
+	// The stop sequence cuts the reply before its first word.
+	events := serveBedrockStream(t, `{"messages": [{"role": "user", "content": [{"text": "hi"}]}], "inferenceConfig": {"stopSequences": ["This"]}}`)
+	var stop *bedrockEvent
+	for i := range events {
+		if events[i].Type == "messageStop" {
+			stop = &events[i]
+		}
+	}
+	if stop == nil || stop.Payload["stopReason"] != "stop_sequence" {
+		t.Errorf("Expected a messageStop with the stop_sequence reason, got %v", events)
+	}
+}
+
+func TestBedrockStreamEvents(t *testing.T) {
+	events := serveBedrockStream(t, `{"messages": [{"role": "user", "content": [{"text": "hi"}]}]}`)
+	var types []string
+	for _, event := range events {
+		if len(types) == 0 || types[len(types)-1] != event.Type {
+			types = append(types, event.Type)
+		}
+	}
+	expected := []string{"messageStart", "contentBlockDelta", "contentBlockStop", "messageStop", "metadata"}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected the events %v, got %v", expected, types)
+	}
+	if role := events[0].Payload["role"]; role != "assistant" {
+		t.Errorf("Expected the messageStart role assistant, got %v", role)
+	}
+	if index := events[len(events)-3].Payload["contentBlockIndex"]; index != float64(0) {
+		t.Errorf("Expected the contentBlockStop of block 0, got %v", index)
+	}
+}
@@ -0,0 +1,380 @@
+package chat
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/generator"
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mockBehaviorKey = "mockBehavior"
+
+// mockBehavior is the behaviour resolved for a single request. Providers consult it through the
+// helpers below instead of hard-coding the reply, the streaming delay or the usage.
+type mockBehavior struct {
+	// Reply replaces the echoed prompt when not empty.
+	Reply string
+	// Generator generates the reply instead of echoing the prompt when not nil.
+	Generator *config.GeneratorConfig
+	// Model is the model of the request.
+	Model string
+	// Latency is waited before the provider handles the request.
+	Latency time.Duration
+	// ChunkDelay replaces the provider's default delay between stream chunks when not zero.
+	ChunkDelay time.Duration
+	// Usage replaces the usage counted by the tokenizer when not nil.
+	Usage *usage
+	// Chunks is the number of stream chunks the reply is split into. Zero keeps the provider's split.
+	Chunks int
+	// Finish is the canonical finish reason (see finishStop...), or a provider-native one.
+	Finish string
+	// Tokenizer counts the prompt and completion tokens of the usage.
+	Tokenizer tokenizer.Tokenizer
+	// Prompt holds the text of every message of the request, counted as prompt tokens.
+	Prompt []string
+	// MaxTokens truncates the reply to the given number of tokens when not zero.
+	MaxTokens int
+	// Stop cuts the reply at the first of the stop sequences.
+	Stop []string
+	// StopSequence is the stop sequence the reply was cut at, if any.
+	StopSequence string
+	// reported is the last usage the provider reported through mockUsage, charged to the rate limiter.
+	reported *usage
+}
+
+// Canonical finish reasons, translated by every provider into its native finish reason.
+const (
+	finishStop          = "stop"
+	finishLength        = "length"
+	finishToolCalls     = "tool_calls"
+	finishContentFilter = "content_filter"
+	// finishStopSequence is reported when the reply was cut at a stop sequence. Providers that do
+	// not distinguish it report finishStop.
+	finishStopSequence = "stop_sequence"
+)
+
+func newMockBehavior(providerConfig *config.ProviderConfig, requestCtx requestContext) *mockBehavior {
+	behavior := &mockBehavior{Model: requestCtx.Model, Prompt: requestCtx.Messages, MaxTokens: requestCtx.MaxTokens, Stop: requestCtx.Stop}
+	// A "mock-<strategy>" model overrides the generator of the provider.
+	modelGenerator := generator.ForModel(requestCtx.Model)
+	if providerConfig == nil {
+		behavior.Generator = modelGenerator
+		behavior.Tokenizer = tokenizer.ForModel(requestCtx.Model)
+		return behavior
+	}
+	behavior.Generator = providerConfig.Generator
+	if modelGenerator != nil {
+		behavior.Generator = modelGenerator
+	}
+	behavior.Tokenizer = tokenizer.Select(providerConfig.Tokenizer, requestCtx.Model)
+	behavior.Reply = providerConfig.Reply
+	behavior.Latency = providerConfig.Latency.Duration()
+	behavior.ChunkDelay = providerConfig.ChunkDelay.Duration()
+	if providerConfig.Usage != nil {
+		behavior.Usage = &usage{
+			PromptTokens:     providerConfig.Usage.PromptTokens,
+			CompletionTokens: providerConfig.Usage.CompletionTokens,
+			TotalTokens:      providerConfig.Usage.TotalTokens,
+		}
+	}
+	return behavior
+}
+
+// apply overrides the behaviour with the scenario requested for the request.
+func (b *mockBehavior) apply(scenario *config.Scenario) {
+	if scenario == nil {
+		return
+	}
+	if scenario.Generator != nil {
+		b.Generator, b.Reply = scenario.Generator, ""
+	}
+	if scenario.Reply != "" {
+		b.Reply = scenario.Reply
+	}
+	if scenario.ChunkDelay > 0 {
+		b.ChunkDelay = scenario.ChunkDelay.Duration()
+	}
+	if scenario.Chunks > 0 {
+		b.Chunks = scenario.Chunks
+	}
+	if scenario.Finish != "" {
+		b.Finish = scenario.Finish
+	}
+}
+
+// getMockBehavior returns the behaviour of the request, or the default behaviour if none was set.
+func getMockBehavior(ctx *gin.Context) *mockBehavior {
+	if value, exists := ctx.Get(mockBehaviorKey); exists {
+		if behavior, ok := value.(*mockBehavior); ok {
+			return behavior
+		}
+	}
+	return &mockBehavior{}
+}
+
+// chunkDelay returns the delay between two stream chunks, falling back to the provider default.
+func chunkDelay(ctx *gin.Context, fallback time.Duration) time.Duration {
+	if delay := getMockBehavior(ctx).ChunkDelay; delay > 0 {
+		return delay
+	}
+	return fallback
+}
+
+// finishReason returns the provider-native finish reason of the request. native translates the
+// canonical finish reasons; values it does not know are passed through as-is, so that a directive
+// can ask for any provider-specific reason.
+func finishReason(ctx *gin.Context, native map[string]string) string {
+	return currentChoice(ctx, "").finishReason(native)
+}
+
+// mockChoice is the reply of one choice of the request.
+type mockChoice struct {
+	Index int
+	Text  string
+	// Finish is the finish reason of the choice, empty for the default one.
+	Finish string
+	// StopSequence is the stop sequence the reply was cut at, if any.
+	StopSequence string
+	// ToolCalls are the tool calls of the choice, which then has no text.
+	ToolCalls []mockToolCall
+}
+
+// finishReason returns the provider-native finish reason of the choice, like the finishReason function.
+func (c mockChoice) finishReason(native map[string]string) string {
+	finish := c.Finish
+	if finish == "" && c.StopSequence != "" {
+		if reason, ok := native[finishStopSequence]; ok {
+			return reason
+		}
+	}
+	if finish == "" {
+		finish = finishStop
+	}
+	if reason, ok := native[finish]; ok {
+		return reason
+	}
+	return finish
+}
+
+// streamChunks splits the reply into stream chunks, evenly into the requested number of chunks if
+// any, otherwise with the provider's own split.
+func streamChunks(ctx *gin.Context, response string, split func(string) []string) []string {
+	count := getMockBehavior(ctx).Chunks
+	if count <= 0 {
+		return split(response)
+	}
+	runes := []rune(response)
+	if count > len(runes) {
+		count = len(runes)
+	}
+	chunks := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		chunks = append(chunks, string(runes[i*len(runes)/count:(i+1)*len(runes)/count]))
+	}
+	return chunks
+}
+
+// splitRunes streams the reply one character at a time.
+func splitRunes(response string) []string {
+	chunks := make([]string, 0, len(response))
+	for _, r := range response {
+		chunks = append(chunks, string(r))
+	}
+	return chunks
+}
+
+// splitWords streams the reply one word at a time, each word followed by a space.
+func splitWords(response string) []string {
+	words := strings.Fields(response)
+	for i := range words {
+		words[i] += " "
+	}
+	return words
+}
+
+// truncateResponse cuts the reply where a model would have stopped generating it: before the first
+// stop sequence of the request, or at its max tokens, whichever comes first. The finish reason
+// then reports the cut unless another one was requested.
+func truncateResponse(ctx *gin.Context, response string) string {
+	behavior := getMockBehavior(ctx)
+	choice := behavior.cut(response)
+	behavior.Finish, behavior.StopSequence = choice.Finish, choice.StopSequence
+	return choice.Text
+}
+
+// cut returns the choice of the reply, cut like truncateResponse does.
+func (b *mockBehavior) cut(response string) mockChoice {
+	choice := mockChoice{Finish: b.Finish}
+	cut := -1
+	for _, stop := range b.Stop {
+		if index := strings.Index(response, stop); stop != "" && index >= 0 && (cut < 0 || index < cut) {
+			cut, choice.StopSequence = index, stop
+		}
+	}
+	if cut >= 0 {
+		response = response[:cut]
+	}
+	choice.Text = response
+	if b.MaxTokens <= 0 {
+		return choice
+	}
+	choice.Text = b.tokenizer().Truncate(response, b.MaxTokens)
+	if choice.Text != response {
+		// The max tokens were reached before the stop sequence.
+		choice.StopSequence = ""
+		if choice.Finish == "" {
+			choice.Finish = finishLength
+		}
+	}
+	return choice
+}
+
+// responseChoices returns the n choices of the request, each cut like truncateResponse does.
+// generate returns the reply of a choice; cut replies repeating the first one are numbered, see
+// number. The first choice is also reported by finishReason and stopSequence.
+func responseChoices(ctx *gin.Context, n int, generate func(index int) string) []mockChoice {
+	behavior := getMockBehavior(ctx)
+	if n < 1 {
+		n = 1
+	}
+	choices := make([]mockChoice, 0, n)
+	for i := 0; i < n; i++ {
+		choice := behavior.cut(generate(i))
+		if i > 0 && choice.Text == choices[0].Text {
+			choice = behavior.number(choice, i)
+		}
+		choice.Index = i
+		choices = append(choices, choice)
+	}
+	behavior.Finish, behavior.StopSequence = choices[0].Finish, choices[0].StopSequence
+	return choices
+}
+
+// number appends its index to a choice repeating the first one, so that it is distinct and still
+// starts with the reply. A choice with no room left for the suffix within the max tokens stays a
+// repeat rather than going over them.
+func (b *mockBehavior) number(choice mockChoice, index int) mockChoice {
+	numbered := fmt.Sprintf("%s [%d]", choice.Text, index)
+	if b.MaxTokens > 0 && b.tokenizer().Count(numbered) > b.MaxTokens {
+		return choice
+	}
+	choice.Text = numbered
+	return choice
+}
+
+// currentChoice returns the single choice of a reply cut by truncateResponse.
+func currentChoice(ctx *gin.Context, response string) mockChoice {
+	behavior := getMockBehavior(ctx)
+	return mockChoice{Text: response, Finish: behavior.Finish, StopSequence: behavior.StopSequence}
+}
+
+// choiceTexts returns the replies of the choices, including their tool calls.
+func choiceTexts(choices []mockChoice) []string {
+	texts := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		texts = append(texts, choice.Text)
+		texts = append(texts, toolCallTexts(choice.ToolCalls)...)
+	}
+	return texts
+}
+
+// stopSequence returns the stop sequence the reply was cut at, or nil, for the stop_sequence
+// fields of the provider responses.
+func stopSequence(ctx *gin.Context) interface{} {
+	if stop := getMockBehavior(ctx).StopSequence; stop != "" {
+		return stop
+	}
+	return nil
+}
+
+// tokenizer returns the tokenizer of the request, defaulting to cl100k.
+func (b *mockBehavior) tokenizer() tokenizer.Tokenizer {
+	if b.Tokenizer == nil {
+		return tokenizer.Get(tokenizer.CL100K)
+	}
+	return b.Tokenizer
+}
+
+// mockUsage returns the token usage the provider reports for the request and its completions: the
+// configured usage if any, otherwise the tokens counted by the tokenizer of the model.
+func mockUsage(ctx *gin.Context, completions ...string) usage {
+	behavior := getMockBehavior(ctx)
+	var u usage
+	if behavior.Usage != nil {
+		u = *behavior.Usage
+	} else {
+		counter := behavior.tokenizer()
+		u.PromptTokens = counter.CountMessages(behavior.Prompt)
+		for _, completion := range completions {
+			u.CompletionTokens += counter.Count(completion)
+		}
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	behavior.reported = &u
+	return u
+}
+
+// sleepContext waits for the given duration and reports false if the client went away meanwhile.
+func sleepContext(ctx *gin.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Request.Context().Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// errorRenderer is implemented by providers to render mock-injected errors in their native error shape.
+type errorRenderer interface {
+	sendMockError(ctx *gin.Context, statusCode int, message string)
+}
+
+// sendMockError renders an injected error through the provider's native error shape, falling back
+// to the OpenAI error shape for providers without one.
+func sendMockError(ctx *gin.Context, handler requestHandler, statusCode int, message string) {
+	if renderer, ok := handler.(errorRenderer); ok {
+		renderer.sendMockError(ctx, statusCode, message)
+		return
+	}
+	sendOpenAiError(ctx, statusCode, message)
+}
+
+// sendOpenAiError writes an OpenAI-style error body: {"error": {message, type, param, code}}.
+func sendOpenAiError(ctx *gin.Context, statusCode int, message string) {
+	errType, code := openAiErrorTypeAndCode(statusCode)
+	ctx.JSON(statusCode, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errType,
+			"param":   nil,
+			"code":    code,
+		},
+	})
+}
+
+// openAiErrorTypeAndCode returns the error type and code the real OpenAI API pairs with the status code.
+func openAiErrorTypeAndCode(statusCode int) (string, interface{}) {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return "invalid_request_error", "invalid_api_key"
+	case http.StatusForbidden:
+		return "invalid_request_error", "unsupported_country_region_territory"
+	case http.StatusNotFound:
+		return "invalid_request_error", "model_not_found"
+	case http.StatusTooManyRequests:
+		return "requests", "rate_limit_exceeded"
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "server_error", nil
+	}
+	return "invalid_request_error", nil
+}
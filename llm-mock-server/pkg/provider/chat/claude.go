@@ -2,6 +2,7 @@ package chat
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -22,6 +23,15 @@ const (
 	claudeMockRequestId = "req_llm-mock"
 )
 
+// claudeFinishReasons are the stop_reason values of the real Anthropic API.
+var claudeFinishReasons = map[string]string{
+	finishStop:          "end_turn",
+	finishLength:        "max_tokens",
+	finishToolCalls:     "tool_use",
+	finishContentFilter: "refusal",
+	finishStopSequence:  "stop_sequence",
+}
+
 // claudeError writes an Anthropic-style error response: the request-id header plus a body carrying
 // the top-level type/request_id and the nested error object, matching the real API's error shape.
 func claudeError(ctx *gin.Context, status int, errType, message string) {
@@ -33,18 +43,51 @@ func claudeError(ctx *gin.Context, status int, errType, message string) {
 	})
 }
 
+// claudeErrorType returns the error type the real Anthropic API pairs with the status code.
+func claudeErrorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
+
 // claudeMessagesRequest is the Anthropic /v1/messages request shape. ai-proxy sends this
 // after converting the client's OpenAI-format request.
 type claudeMessagesRequest struct {
-	Model    string          `json:"model"`
-	Messages []claudeMessage `json:"messages"`
-	System   json.RawMessage `json:"system,omitempty"`
-	Stream   bool            `json:"stream,omitempty"`
-	Tools    []claudeTool    `json:"tools,omitempty"`
+	Model      string            `json:"model"`
+	Messages   []claudeMessage   `json:"messages"`
+	System     json.RawMessage   `json:"system,omitempty"`
+	Stream     bool              `json:"stream,omitempty"`
+	Tools      []claudeTool      `json:"tools,omitempty"`
+	ToolChoice *claudeToolChoice `json:"tool_choice,omitempty"`
 }
 
 type claudeTool struct {
-	Name string `json:"name"`
+	// Type is empty or "custom" for the client tools, and names the server tools otherwise.
+	Type        string                 `json:"type,omitempty"`
+	Name        string                 `json:"name"`
+	InputSchema map[string]interface{} `json:"input_schema,omitempty"`
+}
+
+type claudeToolChoice struct {
+	// Type is auto, any, tool or none.
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
 }
 
 type claudeMessage struct {
@@ -52,6 +95,108 @@ type claudeMessage struct {
 	Content json.RawMessage `json:"content"` // string or [{type,text,...}]
 }
 
+// claudeContentBlock is a block of a message content: text, tool_use or tool_result.
+type claudeContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	// Id and Name are those of a tool_use block.
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	// ToolUseId and Content are those of a tool_result block, whose content is a string or a
+	// list of blocks.
+	ToolUseId string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+}
+
+// blocks returns the content blocks of the message, a string content being a single text block.
+func (m claudeMessage) blocks() []claudeContentBlock {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return []claudeContentBlock{{Type: "text", Text: s}}
+	}
+	var blocks []claudeContentBlock
+	json.Unmarshal(m.Content, &blocks)
+	return blocks
+}
+
+// mockTools returns the client tools of the request.
+func (r *claudeMessagesRequest) mockTools() []mockTool {
+	var tools []mockTool
+	for _, tool := range r.Tools {
+		if tool.Type == "" || tool.Type == "custom" {
+			tools = append(tools, mockTool{Name: tool.Name, Parameters: tool.InputSchema})
+		}
+	}
+	return tools
+}
+
+// toolUse returns how the tool_choice of the request lets the model use its tools.
+func (r *claudeMessagesRequest) toolUse() toolUse {
+	if r.ToolChoice == nil {
+		return toolUse{Mode: toolAuto}
+	}
+	use := toolUse{Mode: toolAuto, Single: r.ToolChoice.DisableParallelToolUse}
+	switch r.ToolChoice.Type {
+	case "any":
+		use.Mode = toolRequired
+	case "tool":
+		use.Mode, use.Name = toolRequired, r.ToolChoice.Name
+	case "none":
+		use.Mode = toolNone
+	}
+	return use
+}
+
+// validateTools checks the tool_choice and, like the real API, that every tool_result answers a
+// tool_use of the previous assistant message.
+func (r *claudeMessagesRequest) validateTools() error {
+	if r.ToolChoice != nil {
+		switch r.ToolChoice.Type {
+		case "auto", "any", "none":
+		case "tool":
+			found := false
+			for _, tool := range r.Tools {
+				found = found || tool.Name == r.ToolChoice.Name
+			}
+			if !found {
+				return fmt.Errorf("tool_choice: tool %q not found in tools", r.ToolChoice.Name)
+			}
+		default:
+			return fmt.Errorf("tool_choice.type: must be one of auto, any, tool or none")
+		}
+	}
+	for i, message := range r.Messages {
+		for _, block := range message.blocks() {
+			if block.Type != "tool_result" {
+				continue
+			}
+			found := false
+			if i > 0 {
+				for _, previous := range r.Messages[i-1].blocks() {
+					found = found || (previous.Type == "tool_use" && previous.Id == block.ToolUseId)
+				}
+			}
+			if !found {
+				return fmt.Errorf("messages.%d: unexpected tool_use_id found in tool_result blocks: %s. Each tool_result block must have a corresponding tool_use block in the previous message", i, block.ToolUseId)
+			}
+		}
+	}
+	return nil
+}
+
+// afterToolResult reports whether the last message returns the result of a tool call.
+func (r *claudeMessagesRequest) afterToolResult() bool {
+	if len(r.Messages) == 0 {
+		return false
+	}
+	for _, block := range r.Messages[len(r.Messages)-1].blocks() {
+		if block.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
 type claudeProvider struct{}
 
 func (p *claudeProvider) ShouldHandleRequest(ctx *gin.Context) bool {
@@ -63,6 +208,10 @@ func (p *claudeProvider) ShouldHandleRequest(ctx *gin.Context) bool {
 	return context.Host == claudeDomain && context.Path == claudeMessagesPath
 }
 
+func (p *claudeProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	claudeError(ctx, statusCode, claudeErrorType(statusCode), message)
+}
+
 func (p *claudeProvider) HandleChatCompletions(ctx *gin.Context) {
 	// The real API requires the anthropic-version header (ai-proxy always injects it).
 	if ctx.GetHeader("anthropic-version") == "" {
@@ -80,34 +229,37 @@ func (p *claudeProvider) HandleChatCompletions(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	response := lastClaudeUserText(&req)
-
-	// A sentinel prompt makes the mock return the upstream auth error, letting the e2e verify that
-	// ai-proxy surfaces an upstream 401 to the client instead of masking it.
-	if response == "__force_auth_error__" {
-		claudeError(ctx, http.StatusUnauthorized, "authentication_error", "invalid x-api-key")
-		return
-	}
-
-	// When the request carries tools, reply with a tool_use block so the tool-call conversion path
-	// is exercised (the mock always calls the first tool with a fixed argument).
-	if len(req.Tools) > 0 && req.Stream {
-		p.handleToolUseStreamResponse(ctx, req.Tools[0].Name)
+	if err := req.validateTools(); err != nil {
+		claudeError(ctx, http.StatusBadRequest, "invalid_request_error", err.Error())
 		return
 	}
+	prompt := lastClaudeUserText(&req)
 
-	if req.Stream {
-		p.handleStreamResponse(ctx, response)
+	// When the request carries tools, reply with tool_use blocks so the tool-call conversion path
+	// is exercised, until a tool_result asks for the final answer.
+	var choice mockChoice
+	if calls := mockToolCalls(req.mockTools(), req.toolUse(), prompt, req.afterToolResult()); len(calls) > 0 {
+		choice = toolChoices(ctx, 1, calls)[0]
+		if req.Stream {
+			p.handleToolUseStreamResponse(ctx, choice)
+			return
+		}
 	} else {
-		p.handleNonStreamResponse(ctx, response)
+		choice = currentChoice(ctx, prompt2Response(ctx, prompt))
+		if req.Stream {
+			p.handleStreamResponse(ctx, choice.Text)
+			return
+		}
 	}
+	p.handleNonStreamResponse(ctx, choice)
 }
 
-// handleToolUseStreamResponse emits an Anthropic tool_use streaming sequence: message_start,
-// content_block_start (tool_use), input_json_delta chunks, content_block_stop, message_delta
-// (stop_reason tool_use), message_stop.
-func (p *claudeProvider) handleToolUseStreamResponse(ctx *gin.Context, toolName string) {
+// handleToolUseStreamResponse emits an Anthropic tool_use streaming sequence: message_start, then
+// for every tool_use block a content_block_start, input_json_delta chunks and a content_block_stop,
+// then message_delta (stop_reason tool_use) and message_stop.
+func (p *claudeProvider) handleToolUseStreamResponse(ctx *gin.Context, choice mockChoice) {
 	utils.SetEventStreamHeaders(ctx)
+	u := mockUsage(ctx, choiceTexts([]mockChoice{choice})...)
 	send := func(payload gin.H) bool {
 		data, _ := json.Marshal(payload)
 		select {
@@ -127,44 +279,62 @@ func (p *claudeProvider) handleToolUseStreamResponse(ctx *gin.Context, toolName
 	if !send(gin.H{"type": "message_start", "message": gin.H{
 		"id": claudeMockId, "type": "message", "role": roleAssistant, "model": claudeMockModel,
 		"content": []gin.H{}, "stop_reason": nil, "stop_sequence": nil,
-		"usage": gin.H{"input_tokens": completionMockUsage.PromptTokens, "output_tokens": 1},
+		"usage": gin.H{"input_tokens": u.PromptTokens, "output_tokens": 1},
 	}}) {
 		return
 	}
-	if !send(gin.H{"type": "content_block_start", "index": 0, "content_block": gin.H{
-		"type": "tool_use", "id": "toolu_llm-mock", "name": toolName, "input": gin.H{},
-	}}) {
-		return
-	}
-	// The tool arguments arrive as partial_json fragments that ai-proxy concatenates.
-	for _, frag := range []string{`{"location": `, `"Beijing"}`} {
-		if !send(gin.H{"type": "content_block_delta", "index": 0, "delta": gin.H{"type": "input_json_delta", "partial_json": frag}}) {
+	for i, call := range choice.ToolCalls {
+		if !send(gin.H{"type": "content_block_start", "index": i, "content_block": gin.H{
+			"type": "tool_use", "id": call.id("toolu"), "name": call.Name, "input": gin.H{},
+		}}) {
+			return
+		}
+		// The tool arguments arrive as partial_json fragments that ai-proxy concatenates.
+		for _, fragment := range splitArguments(call.argumentsJSON()) {
+			if !send(gin.H{"type": "content_block_delta", "index": i, "delta": gin.H{"type": "input_json_delta", "partial_json": fragment}}) {
+				return
+			}
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
+			}
+		}
+		if !send(gin.H{"type": "content_block_stop", "index": i}) {
 			return
 		}
 	}
-	send(gin.H{"type": "content_block_stop", "index": 0})
-	send(gin.H{"type": "message_delta", "delta": gin.H{"stop_reason": "tool_use", "stop_sequence": nil}, "usage": gin.H{"output_tokens": completionMockUsage.CompletionTokens}})
+	send(gin.H{"type": "message_delta", "delta": gin.H{"stop_reason": choice.finishReason(claudeFinishReasons), "stop_sequence": nil}, "usage": gin.H{"output_tokens": u.CompletionTokens}})
 	send(gin.H{"type": "message_stop"})
 }
 
-func (p *claudeProvider) handleNonStreamResponse(ctx *gin.Context, response string) {
+func (p *claudeProvider) handleNonStreamResponse(ctx *gin.Context, choice mockChoice) {
+	u := mockUsage(ctx, choiceTexts([]mockChoice{choice})...)
+	content := []gin.H{{"type": "text", "text": choice.Text}}
+	if len(choice.ToolCalls) > 0 {
+		content = content[:0]
+		for _, call := range choice.ToolCalls {
+			content = append(content, gin.H{"type": "tool_use", "id": call.id("toolu"), "name": call.Name, "input": call.Arguments})
+		}
+	}
 	ctx.JSON(http.StatusOK, gin.H{
 		"id":            claudeMockId,
 		"type":          "message",
 		"role":          roleAssistant,
 		"model":         claudeMockModel,
-		"content":       []gin.H{{"type": "text", "text": response}},
-		"stop_reason":   "end_turn",
-		"stop_sequence": nil,
+		"content":       content,
+		"stop_reason":   choice.finishReason(claudeFinishReasons),
+		"stop_sequence": stopSequence(ctx),
 		"usage": gin.H{
-			"input_tokens":  completionMockUsage.PromptTokens,
-			"output_tokens": completionMockUsage.CompletionTokens,
+			"input_tokens":  u.PromptTokens,
+			"output_tokens": u.CompletionTokens,
 		},
 	})
 }
 
 func (p *claudeProvider) handleStreamResponse(ctx *gin.Context, response string) {
 	utils.SetEventStreamHeaders(ctx)
+	u := mockUsage(ctx, response)
 
 	send := func(payload gin.H) bool {
 		data, _ := json.Marshal(payload)
@@ -187,7 +357,7 @@ func (p *claudeProvider) handleStreamResponse(ctx *gin.Context, response string)
 	if !send(gin.H{"type": "message_start", "message": gin.H{
 		"id": claudeMockId, "type": "message", "role": roleAssistant, "model": claudeMockModel,
 		"content": []gin.H{}, "stop_reason": nil, "stop_sequence": nil,
-		"usage": gin.H{"input_tokens": completionMockUsage.PromptTokens, "output_tokens": 1},
+		"usage": gin.H{"input_tokens": u.PromptTokens, "output_tokens": 1},
 	}}) {
 		return
 	}
@@ -196,46 +366,48 @@ func (p *claudeProvider) handleStreamResponse(ctx *gin.Context, response string)
 	}
 
 	// One text_delta per rune, mirroring the byte-by-byte streaming of the other provider mocks.
-	for _, r := range response {
-		if !send(gin.H{"type": "content_block_delta", "index": 0, "delta": gin.H{"type": "text_delta", "text": string(r)}}) {
+	for _, chunk := range streamChunks(ctx, response, splitRunes) {
+		if !send(gin.H{"type": "content_block_delta", "index": 0, "delta": gin.H{"type": "text_delta", "text": chunk}}) {
 			return
 		}
 		select {
 		case <-ctx.Request.Context().Done():
 			return
-		case <-time.After(50 * time.Millisecond):
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 		}
 	}
 
 	send(gin.H{"type": "content_block_stop", "index": 0})
 	// message_delta.usage.output_tokens is the cumulative total for the whole message, matching the real Anthropic API.
-	send(gin.H{"type": "message_delta", "delta": gin.H{"stop_reason": "end_turn", "stop_sequence": nil}, "usage": gin.H{"output_tokens": completionMockUsage.CompletionTokens}})
+	send(gin.H{"type": "message_delta", "delta": gin.H{"stop_reason": finishReason(ctx, claudeFinishReasons), "stop_sequence": stopSequence(ctx)}, "usage": gin.H{"output_tokens": u.CompletionTokens}})
 	send(gin.H{"type": "message_stop"})
 }
 
 // lastClaudeUserText returns the text of the last message, handling both string and
-// content-block-array content forms.
+// content-block-array content forms, with the content of its tool results.
 func lastClaudeUserText(req *claudeMessagesRequest) string {
 	if len(req.Messages) == 0 {
 		return ""
 	}
-	last := req.Messages[len(req.Messages)-1]
-	var s string
-	if err := json.Unmarshal(last.Content, &s); err == nil {
-		return s
-	}
-	var blocks []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	}
-	if err := json.Unmarshal(last.Content, &blocks); err == nil {
-		text := ""
-		for _, b := range blocks {
-			if b.Type == "text" {
-				text += b.Text
-			}
+	text := ""
+	for _, block := range req.Messages[len(req.Messages)-1].blocks() {
+		switch block.Type {
+		case "text":
+			text += block.Text
+		case "tool_result":
+			text += claudeMessage{Content: block.Content}.text()
+		}
+	}
+	return text
+}
+
+// text returns the text blocks of the content joined.
+func (m claudeMessage) text() string {
+	text := ""
+	for _, block := range m.blocks() {
+		if block.Type == "text" {
+			text += block.Text
 		}
-		return text
 	}
-	return ""
+	return text
 }
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestClaudeValidateTools(t *testing.T) {
+	const tools = `"tools": [{"name": "get_time", "input_schema": {"type": "object"}}]`
+	tests := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{name: "tool choice", body: `{"tool_choice": {"type": "tool", "name": "get_time"}, ` + tools + `}`},
+		{name: "unknown tool", body: `{"tool_choice": {"type": "tool", "name": "get_weather"}, ` + tools + `}`, error: "not found in tools"},
+		{name: "unknown type", body: `{"tool_choice": {"type": "required"}, ` + tools + `}`, error: "must be one of"},
+		{
+			name: "tool loop",
+			body: `{"messages": [
+				{"role": "user", "content": "time?"},
+				{"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_1", "name": "get_time", "input": {}}]},
+				{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "12:00"}]}
+			], ` + tools + `}`,
+		},
+		{
+			name:  "unmatched tool result",
+			body:  `{"messages": [{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "12:00"}]}]}`,
+			error: "unexpected tool_use_id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req claudeMessagesRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			err := req.validateTools()
+			if tt.error == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.error != "" && (err == nil || !strings.Contains(err.Error(), tt.error)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
@@ -22,6 +22,15 @@ type cohereRequest struct {
 	Stream  bool   `json:"stream"`
 }
 
+// cohereFinishReasons are the finish_reason values of the real Cohere v1 chat API.
+var cohereFinishReasons = map[string]string{
+	finishStop:          "COMPLETE",
+	finishLength:        "MAX_TOKENS",
+	finishToolCalls:     "COMPLETE",
+	finishContentFilter: "ERROR_TOXIC",
+	finishStopSequence:  "STOP_SEQUENCE",
+}
+
 type cohereProvider struct{}
 
 func (p *cohereProvider) ShouldHandleRequest(ctx *gin.Context) bool {
@@ -47,18 +56,20 @@ func (p *cohereProvider) HandleChatCompletions(ctx *gin.Context) {
 	}
 
 	// This native Cohere response is what ai-proxy converts to OpenAI shape.
+	response := prompt2Response(ctx, req.Message)
 	if req.Stream {
-		p.handleStreamResponse(ctx, req.Message)
+		p.handleStreamResponse(ctx, response)
 	} else {
-		p.handleNonStreamResponse(ctx, req.Message)
+		p.handleNonStreamResponse(ctx, response)
 	}
 }
 
 // cohereMeta builds the Cohere v1 "meta" object, which carries api_version plus tokens and billed_units.
-func cohereMeta() gin.H {
+func cohereMeta(ctx *gin.Context, response string) gin.H {
+	u := mockUsage(ctx, response)
 	counts := gin.H{
-		"input_tokens":  completionMockUsage.PromptTokens,
-		"output_tokens": completionMockUsage.CompletionTokens,
+		"input_tokens":  u.PromptTokens,
+		"output_tokens": u.CompletionTokens,
 	}
 	return gin.H{
 		"api_version":  gin.H{"version": "1"},
@@ -67,6 +78,10 @@ func cohereMeta() gin.H {
 	}
 }
 
+func (p *cohereProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	ctx.JSON(statusCode, gin.H{"message": message})
+}
+
 func (p *cohereProvider) handleNonStreamResponse(ctx *gin.Context, response string) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"response_id":   completionMockId,
@@ -76,8 +91,8 @@ func (p *cohereProvider) handleNonStreamResponse(ctx *gin.Context, response stri
 			{"role": "USER", "message": response},
 			{"role": "CHATBOT", "message": response},
 		},
-		"finish_reason": "COMPLETE",
-		"meta":          cohereMeta(),
+		"finish_reason": finishReason(ctx, cohereFinishReasons),
+		"meta":          cohereMeta(ctx, response),
 	})
 }
 
@@ -103,26 +118,26 @@ func (p *cohereProvider) handleStreamResponse(ctx *gin.Context, response string)
 	if !send(gin.H{"event_type": "stream-start", "generation_id": completionMockId, "is_finished": false}) {
 		return
 	}
-	for _, r := range response {
-		if !send(gin.H{"event_type": "text-generation", "text": string(r), "is_finished": false}) {
+	for _, chunk := range streamChunks(ctx, response, splitRunes) {
+		if !send(gin.H{"event_type": "text-generation", "text": chunk, "is_finished": false}) {
 			return
 		}
 		select {
 		case <-ctx.Request.Context().Done():
 			return
-		case <-time.After(50 * time.Millisecond):
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 		}
 	}
 	send(gin.H{
 		"event_type":    "stream-end",
 		"is_finished":   true,
-		"finish_reason": "COMPLETE",
+		"finish_reason": finishReason(ctx, cohereFinishReasons),
 		"response": gin.H{
 			"response_id":   completionMockId,
 			"text":          response,
 			"generation_id": completionMockId,
-			"finish_reason": "COMPLETE",
-			"meta":          cohereMeta(),
+			"finish_reason": finishReason(ctx, cohereFinishReasons),
+			"meta":          cohereMeta(ctx, response),
 		},
 	})
 }
@@ -35,6 +35,10 @@ func (p *deeplProvider) ShouldHandleRequest(ctx *gin.Context) bool {
 	return (context.Host == deeplHostFree || context.Host == deeplHostPro) && context.Path == deeplTranslatePath
 }
 
+func (p *deeplProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	ctx.JSON(statusCode, gin.H{"message": message})
+}
+
 func (p *deeplProvider) HandleChatCompletions(ctx *gin.Context) {
 	// The real DeepL API authenticates with "Authorization: DeepL-Auth-Key <key>"; ai-proxy injects it.
 	if ctx.GetHeader("Authorization") == "" {
@@ -51,7 +55,7 @@ func (p *deeplProvider) HandleChatCompletions(ctx *gin.Context) {
 	// DeepL returns one translation per input text; echo each entry back.
 	translations := make([]gin.H, 0, len(req.Text))
 	for _, t := range req.Text {
-		translations = append(translations, gin.H{"detected_source_language": deeplDetectedSource, "text": t})
+		translations = append(translations, gin.H{"detected_source_language": deeplDetectedSource, "text": prompt2Response(ctx, t)})
 	}
 
 	// DeepL translation is non-streaming; ai-proxy converts this to an OpenAI chat.completion.
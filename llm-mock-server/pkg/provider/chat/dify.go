@@ -54,7 +54,7 @@ func (p *difyProvider) HandleChatCompletions(ctx *gin.Context) {
 	}
 
 	// Generate reply based on the query
-	reply := prompt2Response(chatRequest.Query)
+	reply := prompt2Response(ctx, chatRequest.Query)
 	botType := botTypeChat
 	if ctx.Request.URL.Path == difyCompletionPath {
 		botType = botTypeCompletion
@@ -65,7 +65,7 @@ func (p *difyProvider) HandleChatCompletions(ctx *gin.Context) {
 		}
 
 		if query, ok := query.(string); ok {
-			reply = prompt2Response(query)
+			reply = prompt2Response(ctx, query)
 		} else {
 			p.sendErrorResponse(ctx, 400, "Invalid request: query must be a string for bot type completion")
 			return
@@ -90,16 +90,20 @@ func (p *difyProvider) sendErrorResponse(ctx *gin.Context, statusCode int, messa
 	})
 }
 
+func (p *difyProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	p.sendErrorResponse(ctx, statusCode, message)
+}
+
 func (p *difyProvider) handleStreamResponse(ctx *gin.Context, chatRequest difyChatRequest, botType string, reply string) {
 	utils.SetEventStreamHeaders(ctx)
 	dataChan := make(chan string)
 	stopChan := make(chan bool, 1)
 
 	go func() {
-		for _, s := range reply {
+		for _, chunk := range streamChunks(ctx, reply, splitRunes) {
 			response := difyChunkChatResponse{
 				Event:          "agent_thought",
-				Answer:         string(s),
+				Answer:         chunk,
 				ConversationId: completionMockId,
 				MessageId:      completionMockId,
 				CreatedAt:      completionMockCreated,
@@ -108,7 +112,7 @@ func (p *difyProvider) handleStreamResponse(ctx *gin.Context, chatRequest difyCh
 			dataChan <- string(jsonStr)
 
 			// Simulate response delay
-			time.Sleep(200 * time.Millisecond)
+			time.Sleep(chunkDelay(ctx, 200*time.Millisecond))
 		}
 		stopChan <- true
 	}()
@@ -126,7 +130,7 @@ func (p *difyProvider) handleStreamResponse(ctx *gin.Context, chatRequest difyCh
 				ConversationId: completionMockId,
 				MessageId:      completionMockId,
 				MetaData: difyMetaData{
-					Usage: completionMockUsage,
+					Usage: mockUsage(ctx, reply),
 				},
 			}
 			jsonStr, _ := json.Marshal(finalResponse)
@@ -143,7 +147,7 @@ func (p *difyProvider) handleNonStreamResponse(ctx *gin.Context, chatRequest dif
 		MessageId:      completionMockId,
 		CreatedAt:      completionMockCreated,
 		MetaData: difyMetaData{
-			Usage: completionMockUsage,
+			Usage: mockUsage(ctx, reply),
 		},
 	}
 	ctx.JSON(http.StatusOK, response)
@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"llm-mock-server/pkg/config"
+)
+
+const (
+	// mockDirectivePrefix starts an in-prompt control directive, e.g.
+	// __mock__{"status":429,"delay":"2s","chunks":3,"finish":"length"}
+	mockDirectivePrefix = "__mock__"
+	// legacyAuthErrorPrompt is the former sentinel of the Claude mock, kept there as an alias of
+	// __mock__{"status":401}.
+	legacyAuthErrorPrompt = "__force_auth_error__"
+)
+
+// parseMockDirective returns the scenario requested by a directive in the prompt, or nil if there
+// is none. Directives let test authors who only control the client request drive the mock.
+func parseMockDirective(prompt string) (*config.Scenario, error) {
+	index := strings.Index(prompt, mockDirectivePrefix)
+	if index < 0 {
+		return nil, nil
+	}
+	directive := &config.Scenario{}
+	decoder := json.NewDecoder(strings.NewReader(prompt[index+len(mockDirectivePrefix):]))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(directive); err != nil {
+		return nil, fmt.Errorf("invalid %s directive: %v", mockDirectivePrefix, err)
+	}
+	if err := directive.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s directive: %v", mockDirectivePrefix, err)
+	}
+	return directive, nil
+}
+
+// legacyAuthError returns the scenario of the legacy sentinel if the Claude mock receives it, or
+// nil otherwise. The other providers treat the sentinel as a plain prompt.
+func legacyAuthError(provider, prompt string) *config.Scenario {
+	if provider != "claude" || strings.TrimSpace(prompt) != legacyAuthErrorPrompt {
+		return nil
+	}
+	return &config.Scenario{Status: http.StatusUnauthorized, Message: "invalid x-api-key"}
+}
+
+// stripMockDirective removes the directive from the prompt so that it is not echoed back.
+func stripMockDirective(prompt string) string {
+	index := strings.Index(prompt, mockDirectivePrefix)
+	if index < 0 {
+		return prompt
+	}
+	rest := prompt[index+len(mockDirectivePrefix):]
+	decoder := json.NewDecoder(strings.NewReader(rest))
+	var directive json.RawMessage
+	if err := decoder.Decode(&directive); err != nil {
+		return prompt
+	}
+	return strings.TrimSpace(prompt[:index] + rest[decoder.InputOffset():])
+}
@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMockDirective(t *testing.T) {
+	directive, err := parseMockDirective(`hi __mock__{"status":429,"delay":"2s","chunks":3,"finish":"length"} there`)
+	if err != nil {
+		t.Fatalf("Failed to parse directive: %v", err)
+	}
+	if directive.Status != 429 || directive.Delay.Duration() != 2*time.Second || directive.Chunks != 3 || directive.Finish != finishLength {
+		t.Errorf("Unexpected directive: %+v", directive)
+	}
+
+	if directive, err := parseMockDirective("no directive"); directive != nil || err != nil {
+		t.Errorf("Expected no directive, got (%+v, %v)", directive, err)
+	}
+	if directive, _ := parseMockDirective(legacyAuthErrorPrompt); directive != nil {
+		t.Errorf("Expected the legacy sentinel not to be a directive, got %+v", directive)
+	}
+	if directive := legacyAuthError("claude", legacyAuthErrorPrompt); directive == nil || directive.Status != 401 {
+		t.Errorf("Expected the legacy sentinel to fail Claude requests with 401, got %+v", directive)
+	}
+	if directive := legacyAuthError("openai", legacyAuthErrorPrompt); directive != nil {
+		t.Errorf("Expected the legacy sentinel to be limited to Claude, got %+v", directive)
+	}
+	for _, prompt := range []string{`__mock__{"status":200}`, `__mock__{"unknown":1}`, `__mock__{"status":`} {
+		if _, err := parseMockDirective(prompt); err == nil {
+			t.Errorf("Expected %s to be rejected", prompt)
+		}
+	}
+}
+
+func TestStripMockDirective(t *testing.T) {
+	tests := map[string]string{
+		`hi __mock__{"chunks":3} there`: "hi  there",
+		`__mock__{"finish":"length"}`:   "",
+		"plain prompt":                  "plain prompt",
+	}
+	for prompt, expected := range tests {
+		if stripped := stripMockDirective(prompt); stripped != expected {
+			t.Errorf("stripMockDirective(%q) = %q, expected %q", prompt, stripped, expected)
+		}
+	}
+}
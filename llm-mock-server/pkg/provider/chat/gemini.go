@@ -16,6 +16,14 @@ const (
 	geminiPath   = "/v1beta/models/"
 )
 
+// geminiFinishReasons are the finishReason values of the real Gemini API. Function calls finish with STOP.
+var geminiFinishReasons = map[string]string{
+	finishStop:          "STOP",
+	finishLength:        "MAX_TOKENS",
+	finishToolCalls:     "STOP",
+	finishContentFilter: "SAFETY",
+}
+
 type geminiProvider struct{}
 
 func (p *geminiProvider) ShouldHandleRequest(ctx *gin.Context) bool {
@@ -58,7 +66,7 @@ func (p *geminiProvider) HandleChatCompletions(ctx *gin.Context) {
 	}
 
 	// Validate request body
-	if err := p.validateRequest(&geminiRequest); err != nil {
+	if err := geminiRequest.validate(); err != nil {
 		p.sendErrorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("Validation error: %v", err.Error()))
 		return
 	}
@@ -67,12 +75,12 @@ func (p *geminiProvider) HandleChatCompletions(ctx *gin.Context) {
 	isStreaming := action == "streamGenerateContent"
 
 	// Generate the reply content
-	content := p.generateResponse(&geminiRequest)
+	choices := generateContentChoices(ctx, &geminiRequest, "This is a mock response from Gemini provider. ")
 
 	if isStreaming {
-		p.handleStreamResponse(ctx, &geminiRequest, content)
+		streamGenerateContent(ctx, choices)
 	} else {
-		p.handleNonStreamResponse(ctx, &geminiRequest, content)
+		ctx.JSON(http.StatusOK, generateContentResponse(ctx, choices))
 	}
 }
 
@@ -88,7 +96,8 @@ func parseGeminiModelAndAction(path string) (string, string, bool) {
 	return parts[0], parts[1], true
 }
 
-func (p *geminiProvider) validateRequest(req *geminiGenerateContentRequest) error {
+// validate checks the contents of a generateContent request, which Gemini and Vertex share.
+func (req *geminiGenerateContentRequest) validate() error {
 	if len(req.Contents) == 0 {
 		return fmt.Errorf("contents are required")
 	}
@@ -98,8 +107,8 @@ func (p *geminiProvider) validateRequest(req *geminiGenerateContentRequest) erro
 			return fmt.Errorf("content %d: parts are required", i)
 		}
 		for j, part := range content.Parts {
-			if part.Text == "" {
-				return fmt.Errorf("content %d, part %d: text is required", i, j)
+			if part.Text == "" && part.FunctionCall == nil && part.FunctionResponse == nil {
+				return fmt.Errorf("content %d, part %d: text, functionCall or functionResponse is required", i, j)
 			}
 		}
 	}
@@ -107,13 +116,21 @@ func (p *geminiProvider) validateRequest(req *geminiGenerateContentRequest) erro
 	return nil
 }
 
-func (p *geminiProvider) generateResponse(req *geminiGenerateContentRequest) string {
-	// Generate the mock reply content
-	content := "This is a mock response from Gemini provider. "
+// generateContentChoices returns the candidates of a generateContent request: the function calls,
+// or the intro of the provider followed by the last message.
+func generateContentChoices(ctx *gin.Context, req *geminiGenerateContentRequest, intro string) []mockChoice {
+	candidateCount := 0
+	if req.GenerationConfig != nil {
+		candidateCount = req.GenerationConfig.CandidateCount
+	}
+	content := intro
 	if len(req.Contents) > 0 {
 		lastContent := req.Contents[len(req.Contents)-1]
+		if calls := mockToolCalls(req.mockTools(), req.toolUse(), lastContent.text(), lastContent.hasFunctionResponse()); len(calls) > 0 {
+			return toolChoices(ctx, candidateCount, calls)
+		}
 		if len(lastContent.Parts) > 0 {
-			runes := []rune(lastContent.Parts[0].Text)
+			runes := []rune(stripMockDirective(lastContent.text()))
 			if len(runes) > 50 {
 				content += "You said: " + string(runes[:50]) + "..."
 			} else {
@@ -121,21 +138,46 @@ func (p *geminiProvider) generateResponse(req *geminiGenerateContentRequest) str
 			}
 		}
 	}
-	return content
+	return responseChoices(ctx, candidateCount, func(index int) string {
+		if response, ok := configuredChoice(ctx, index); ok {
+			return response
+		}
+		return content
+	})
 }
 
-func (p *geminiProvider) handleStreamResponse(ctx *gin.Context, req *geminiGenerateContentRequest, response string) {
+// streamGenerateContent streams the candidates in the streamGenerateContent format of Gemini and Vertex.
+func streamGenerateContent(ctx *gin.Context, choices []mockChoice) {
 	// Set streaming response headers
 	ctx.Header("Content-Type", "text/event-stream")
 	ctx.Header("Cache-Control", "no-cache")
 	ctx.Header("Connection", "keep-alive")
 	ctx.Header("Access-Control-Allow-Origin", "*")
 
-	// Split the reply into words and stream them
-	words := strings.Fields(response)
-	totalWords := len(words)
+	// Split every candidate into words (or the requested number of chunks). Each chunk carries
+	// the next piece of every candidate that still has one.
+	// Function calls are not split: they arrive whole in a single chunk.
+	pieces := make([][][]geminiPart, len(choices))
+	steps := 0
+	for i, choice := range choices {
+		if len(choice.ToolCalls) > 0 {
+			pieces[i] = [][]geminiPart{geminiParts(choice)}
+		} else {
+			for _, text := range streamChunks(ctx, choice.Text, splitWords) {
+				pieces[i] = append(pieces[i], []geminiPart{{Text: text}})
+			}
+			// A candidate cut to nothing by a stop sequence still ends with its finish reason.
+			if len(pieces[i]) == 0 {
+				pieces[i] = [][]geminiPart{{{Text: ""}}}
+			}
+		}
+		if len(pieces[i]) > steps {
+			steps = len(pieces[i])
+		}
+	}
 
-	for i, word := range words {
+	u := mockUsage(ctx, choiceTexts(choices)...)
+	for step := 0; step < steps; step++ {
 		select {
 		case <-ctx.Request.Context().Done():
 			return
@@ -143,27 +185,28 @@ func (p *geminiProvider) handleStreamResponse(ctx *gin.Context, req *geminiGener
 		}
 
 		chunk := geminiGenerateContentResponse{
-			Candidates: []geminiCandidate{
-				{
-					Content: geminiContent{
-						Parts: []geminiPart{
-							{Text: word + " "},
-						},
-						Role: "model",
-					},
-					Index: 0,
-				},
-			},
 			UsageMetadata: &geminiUsageMetadata{
-				PromptTokenCount:     completionMockUsage.PromptTokens,
-				CandidatesTokenCount: completionMockUsage.CompletionTokens,
-				TotalTokenCount:      completionMockUsage.TotalTokens,
+				PromptTokenCount:     u.PromptTokens,
+				CandidatesTokenCount: u.CompletionTokens,
+				TotalTokenCount:      u.TotalTokens,
 			},
 		}
-
-		// The final chunk carries the finish reason
-		if i == totalWords-1 {
-			chunk.Candidates[0].FinishReason = "STOP"
+		for i, choice := range choices {
+			if step >= len(pieces[i]) {
+				continue
+			}
+			candidate := geminiCandidate{
+				Content: geminiContent{
+					Parts: pieces[i][step],
+					Role:  "model",
+				},
+				Index: choice.Index,
+			}
+			// The final chunk of a candidate carries its finish reason
+			if step == len(pieces[i])-1 {
+				candidate.FinishReason = choice.finishReason(geminiFinishReasons)
+			}
+			chunk.Candidates = append(chunk.Candidates, candidate)
 		}
 
 		// Send the data chunk, in the same way as the OpenAI provider
@@ -180,34 +223,49 @@ func (p *geminiProvider) handleStreamResponse(ctx *gin.Context, req *geminiGener
 		select {
 		case <-ctx.Request.Context().Done():
 			return
-		case <-time.After(50 * time.Millisecond):
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 		}
 	}
 }
 
-func (p *geminiProvider) handleNonStreamResponse(ctx *gin.Context, req *geminiGenerateContentRequest, response string) {
-	// Build the non-streaming response
-	geminiResponse := geminiGenerateContentResponse{
-		Candidates: []geminiCandidate{
-			{
-				Content: geminiContent{
-					Parts: []geminiPart{
-						{Text: response},
-					},
-					Role: "model",
-				},
-				FinishReason: "STOP",
-				Index:        0,
+// generateContentResponse returns the non-streaming response of Gemini and Vertex.
+func generateContentResponse(ctx *gin.Context, choices []mockChoice) geminiGenerateContentResponse {
+	u := mockUsage(ctx, choiceTexts(choices)...)
+	candidates := make([]geminiCandidate, 0, len(choices))
+	for _, choice := range choices {
+		candidates = append(candidates, geminiCandidate{
+			Content: geminiContent{
+				Parts: geminiParts(choice),
+				Role:  "model",
 			},
-		},
+			FinishReason: choice.finishReason(geminiFinishReasons),
+			Index:        choice.Index,
+		})
+	}
+	return geminiGenerateContentResponse{
+		Candidates: candidates,
 		UsageMetadata: &geminiUsageMetadata{
-			PromptTokenCount:     completionMockUsage.PromptTokens,
-			CandidatesTokenCount: completionMockUsage.CompletionTokens,
-			TotalTokenCount:      completionMockUsage.TotalTokens,
+			PromptTokenCount:     u.PromptTokens,
+			CandidatesTokenCount: u.CompletionTokens,
+			TotalTokenCount:      u.TotalTokens,
 		},
 	}
+}
 
-	ctx.JSON(http.StatusOK, geminiResponse)
+// geminiParts returns the parts of a candidate: its text, or a functionCall part per tool call.
+func geminiParts(choice mockChoice) []geminiPart {
+	if len(choice.ToolCalls) == 0 {
+		return []geminiPart{{Text: choice.Text}}
+	}
+	parts := make([]geminiPart, 0, len(choice.ToolCalls))
+	for _, call := range choice.ToolCalls {
+		parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: call.Name, Args: call.Arguments}})
+	}
+	return parts
+}
+
+func (p *geminiProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	p.sendErrorResponse(ctx, statusCode, message)
 }
 
 func (p *geminiProvider) sendErrorResponse(ctx *gin.Context, statusCode int, message string) {
@@ -233,16 +291,61 @@ func geminiErrorStatus(statusCode int) string {
 		return "NOT_FOUND"
 	case http.StatusTooManyRequests:
 		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
 	default:
 		return "INTERNAL"
 	}
 }
 
-// Data structures of Gemini requests and responses
+// Data structures of the generateContent requests and responses, which Gemini and Vertex share.
 type geminiGenerateContentRequest struct {
 	Contents         []geminiContent         `json:"contents"`
 	SafetySettings   []geminiSafetySetting   `json:"safetySettings,omitempty"`
 	GenerationConfig *geminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools            []geminiTool            `json:"tools,omitempty"`
+	ToolConfig       *geminiToolConfig       `json:"toolConfig,omitempty"`
+}
+
+// mockTools returns the function declarations of the request, restricted to the allowed function
+// names of the ANY mode.
+func (r *geminiGenerateContentRequest) mockTools() []mockTool {
+	allowed := map[string]bool{}
+	if r.ToolConfig != nil && r.ToolConfig.FunctionCallingConfig != nil {
+		for _, name := range r.ToolConfig.FunctionCallingConfig.AllowedFunctionNames {
+			allowed[name] = true
+		}
+	}
+	var tools []mockTool
+	for _, tool := range r.Tools {
+		for _, declaration := range tool.FunctionDeclarations {
+			if len(allowed) > 0 && !allowed[declaration.Name] {
+				continue
+			}
+			parameters := declaration.Parameters
+			if parameters == nil {
+				parameters = declaration.ParametersJsonSchema
+			}
+			tools = append(tools, mockTool{Name: declaration.Name, Parameters: parameters})
+		}
+	}
+	return tools
+}
+
+// toolUse returns how the functionCallingConfig of the request lets the model call functions.
+func (r *geminiGenerateContentRequest) toolUse() toolUse {
+	if r.ToolConfig == nil || r.ToolConfig.FunctionCallingConfig == nil {
+		return toolUse{Mode: toolAuto}
+	}
+	switch r.ToolConfig.FunctionCallingConfig.Mode {
+	case "ANY":
+		return toolUse{Mode: toolRequired}
+	case "NONE":
+		return toolUse{Mode: toolNone}
+	}
+	return toolUse{Mode: toolAuto}
 }
 
 type geminiContent struct {
@@ -251,7 +354,63 @@ type geminiContent struct {
 }
 
 type geminiPart struct {
-	Text string `json:"text"`
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// text returns the text of the content, with the function responses it carries as JSON.
+func (c geminiContent) text() string {
+	var text strings.Builder
+	for _, part := range c.Parts {
+		text.WriteString(part.Text)
+		if part.FunctionResponse != nil {
+			response, _ := json.Marshal(part.FunctionResponse.Response)
+			text.Write(response)
+		}
+	}
+	return text.String()
+}
+
+// hasFunctionResponse reports whether the content returns the result of a function call.
+func (c geminiContent) hasFunctionResponse() bool {
+	for _, part := range c.Parts {
+		if part.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type geminiFunctionCall struct {
+	Name string      `json:"name"`
+	Args interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name                 string                 `json:"name"`
+	Description          string                 `json:"description,omitempty"`
+	Parameters           map[string]interface{} `json:"parameters,omitempty"`
+	ParametersJsonSchema map[string]interface{} `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig *geminiFunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+type geminiFunctionCallingConfig struct {
+	// Mode is AUTO, ANY, NONE or VALIDATED.
+	Mode                 string   `json:"mode,omitempty"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
 }
 
 type geminiSafetySetting struct {
@@ -264,9 +423,12 @@ type geminiGenerationConfig struct {
 	TopP            float64 `json:"topP,omitempty"`
 	TopK            int     `json:"topK,omitempty"`
 	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	CandidateCount  int     `json:"candidateCount,omitempty"`
 }
 
 type geminiGenerateContentResponse struct {
+	// ResponseId is only set by Vertex.
+	ResponseId    string               `json:"responseId,omitempty"`
 	Candidates    []geminiCandidate    `json:"candidates"`
 	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
 }
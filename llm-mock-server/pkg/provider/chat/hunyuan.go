@@ -30,6 +30,11 @@ type hunyuanRequest struct {
 	Stream bool `json:"Stream"`
 }
 
+// hunyuanFinishReasons are the FinishReason values of the real Hunyuan API that differ from OpenAI's.
+var hunyuanFinishReasons = map[string]string{
+	finishContentFilter: "sensitive",
+}
+
 type hunyuanProvider struct{}
 
 func (p *hunyuanProvider) ShouldHandleRequest(ctx *gin.Context) bool {
@@ -63,7 +68,7 @@ func (p *hunyuanProvider) HandleChatCompletions(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"Response": gin.H{"Error": gin.H{"Message": err.Error()}}})
 		return
 	}
-	response := lastHunyuanUserText(&req)
+	response := prompt2Response(ctx, lastHunyuanUserText(&req))
 
 	// The real API returns the request id in the X-TC-RequestId response header.
 	ctx.Header("X-TC-RequestId", completionMockId)
@@ -74,7 +79,32 @@ func (p *hunyuanProvider) HandleChatCompletions(ctx *gin.Context) {
 	}
 }
 
+func (p *hunyuanProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	ctx.JSON(statusCode, gin.H{
+		"Response": gin.H{
+			"Error":     gin.H{"Code": hunyuanErrorCode(statusCode), "Message": message},
+			"RequestId": completionMockId,
+		},
+	})
+}
+
+// hunyuanErrorCode returns the Tencent Cloud error code that matches the status code.
+func hunyuanErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "InvalidParameter"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "AuthFailure"
+	case http.StatusNotFound:
+		return "ResourceNotFound"
+	case http.StatusTooManyRequests:
+		return "RequestLimitExceeded"
+	}
+	return "InternalError"
+}
+
 func (p *hunyuanProvider) handleNonStreamResponse(ctx *gin.Context, response string) {
+	u := mockUsage(ctx, response)
 	ctx.JSON(http.StatusOK, gin.H{
 		"Response": gin.H{
 			"RequestId": completionMockId,
@@ -83,13 +113,13 @@ func (p *hunyuanProvider) handleNonStreamResponse(ctx *gin.Context, response str
 			"Created":   completionMockCreated,
 			"Choices": []gin.H{{
 				"Index":        0,
-				"FinishReason": stopReason,
+				"FinishReason": finishReason(ctx, hunyuanFinishReasons),
 				"Message":      gin.H{"Role": roleAssistant, "Content": response},
 			}},
 			"Usage": gin.H{
-				"PromptTokens":     completionMockUsage.PromptTokens,
-				"CompletionTokens": completionMockUsage.CompletionTokens,
-				"TotalTokens":      completionMockUsage.TotalTokens,
+				"PromptTokens":     u.PromptTokens,
+				"CompletionTokens": u.CompletionTokens,
+				"TotalTokens":      u.TotalTokens,
 			},
 		},
 	})
@@ -97,6 +127,7 @@ func (p *hunyuanProvider) handleNonStreamResponse(ctx *gin.Context, response str
 
 func (p *hunyuanProvider) handleStreamResponse(ctx *gin.Context, response string) {
 	utils.SetEventStreamHeaders(ctx)
+	u := mockUsage(ctx, response)
 
 	// Every frame MUST carry a non-empty Choices array: ai-proxy indexes Choices[0]
 	// without a bounds check when converting Hunyuan chunks.
@@ -111,9 +142,9 @@ func (p *hunyuanProvider) handleStreamResponse(ctx *gin.Context, response string
 				"FinishReason": finish,
 			}},
 			"Usage": gin.H{
-				"PromptTokens":     completionMockUsage.PromptTokens,
-				"CompletionTokens": completionMockUsage.CompletionTokens,
-				"TotalTokens":      completionMockUsage.TotalTokens,
+				"PromptTokens":     u.PromptTokens,
+				"CompletionTokens": u.CompletionTokens,
+				"TotalTokens":      u.TotalTokens,
 			},
 		})
 		select {
@@ -128,17 +159,17 @@ func (p *hunyuanProvider) handleStreamResponse(ctx *gin.Context, response string
 
 	// One content delta per rune, then a terminal frame with finish_reason "stop" (native
 	// Hunyuan emits no [DONE]).
-	for _, r := range response {
-		if !send(string(r), "") {
+	for _, chunk := range streamChunks(ctx, response, splitRunes) {
+		if !send(chunk, "") {
 			return
 		}
 		select {
 		case <-ctx.Request.Context().Done():
 			return
-		case <-time.After(50 * time.Millisecond):
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 		}
 	}
-	send("", stopReason)
+	send("", finishReason(ctx, hunyuanFinishReasons))
 }
 
 func lastHunyuanUserText(req *hunyuanRequest) string {
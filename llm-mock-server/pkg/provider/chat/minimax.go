@@ -59,7 +59,7 @@ func (p *minimaxProvider) HandleChatCompletions(ctx *gin.Context) {
 	senderType := chatRequest.ReplyConstraints.SenderType
 	senderName := chatRequest.ReplyConstraints.SenderName
 	// Generate reply based on the last message in the request
-	reply := prompt2Response(chatRequest.Messages[len(chatRequest.Messages)-1].Text)
+	reply := prompt2Response(ctx, chatRequest.Messages[len(chatRequest.Messages)-1].Text)
 
 	// Handle stream or non-stream response based on the request
 	if chatRequest.Stream {
@@ -79,6 +79,23 @@ func (p *minimaxProvider) sendErrorResponse(ctx *gin.Context, respCode int, resp
 	})
 }
 
+// sendMockError maps the HTTP status to the minimax base_resp status code; like the real API, the
+// error itself is delivered with HTTP 200.
+func (p *minimaxProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	respCode := 1000 // unknown error
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusNotFound:
+		respCode = 2013 // invalid params
+	case http.StatusUnauthorized, http.StatusForbidden:
+		respCode = 1004 // authentication failed
+	case http.StatusTooManyRequests:
+		respCode = 1002 // rate limit triggered
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		respCode = 1001 // timeout
+	}
+	p.sendErrorResponse(ctx, respCode, message)
+}
+
 func (p *minimaxProvider) handleStreamResponse(ctx *gin.Context, chatRequest minimaxChatCompletionProRequest, senderType, senderName, reply string) {
 	utils.SetEventStreamHeaders(ctx)
 	dataChan := make(chan string)
@@ -88,14 +105,14 @@ func (p *minimaxProvider) handleStreamResponse(ctx *gin.Context, chatRequest min
 		Model:   chatRequest.Model,
 	}
 	go func() {
-		for _, s := range reply {
+		for _, chunk := range streamChunks(ctx, reply, splitRunes) {
 			streamResponse.Choices = []minimaxChoice{
 				{
 					Messages: []minimaxMessage{
 						{
 							SenderType: senderType,
 							SenderName: senderName,
-							Text:       string(s),
+							Text:       chunk,
 						},
 					},
 				},
@@ -104,7 +121,7 @@ func (p *minimaxProvider) handleStreamResponse(ctx *gin.Context, chatRequest min
 			dataChan <- string(jsonStr)
 
 			// Simulate response delay
-			time.Sleep(200 * time.Millisecond)
+			time.Sleep(chunkDelay(ctx, 200*time.Millisecond))
 		}
 		stopChan <- true
 	}()
@@ -115,7 +132,7 @@ func (p *minimaxProvider) handleStreamResponse(ctx *gin.Context, chatRequest min
 			ctx.Render(-1, streamEvent{Data: "data: " + data})
 			return true
 		case <-stopChan:
-			jsonStr, _ := json.Marshal(p.createProResp(chatRequest.Model, senderType, senderName, reply))
+			jsonStr, _ := json.Marshal(p.createProResp(ctx, chatRequest.Model, senderType, senderName, reply))
 			ctx.Render(-1, streamEvent{Data: fmt.Sprintf("data: %s", jsonStr)})
 			return false
 		}
@@ -123,11 +140,11 @@ func (p *minimaxProvider) handleStreamResponse(ctx *gin.Context, chatRequest min
 }
 
 func (p *minimaxProvider) handleNonStreamResponse(ctx *gin.Context, chatRequest minimaxChatCompletionProRequest, senderType, senderName, reply string) {
-	completion := p.createProResp(chatRequest.Model, senderType, senderName, reply)
+	completion := p.createProResp(ctx, chatRequest.Model, senderType, senderName, reply)
 	ctx.JSON(http.StatusOK, completion)
 }
 
-func (p *minimaxProvider) createProResp(model, senderType, senderName, reply string) minimaxChatCompletionProResp {
+func (p *minimaxProvider) createProResp(ctx *gin.Context, model, senderType, senderName, reply string) minimaxChatCompletionProResp {
 	return minimaxChatCompletionProResp{
 		Created:         completionMockCreated,
 		Model:           model,
@@ -144,10 +161,10 @@ func (p *minimaxProvider) createProResp(model, senderType, senderName, reply str
 						Text:       reply,
 					},
 				},
-				FinishReason: stopReason,
+				FinishReason: finishReason(ctx, nil),
 			},
 		},
-		Usage: completionMockUsage,
+		Usage: mockUsage(ctx, reply),
 		Id:    completionMockId,
 		BaseResp: minimaxBaseResp{
 			StatusCode: 0,
@@ -12,39 +12,88 @@ const (
 	objectChatCompletionChunk = "chat.completion.chunk"
 
 	roleAssistant = "assistant"
+	roleTool      = "tool"
 
-	stopReason = "stop"
+	toolTypeFunction = "function"
 
 	contentTypeText     = "text"
 	contentTypeImageUrl = "image_url"
 )
 
-var (
-	completionMockCreated int64 = 10
-	completionMockUsage         = usage{
-		PromptTokens:     9,
-		CompletionTokens: 1,
-		TotalTokens:      10,
-	}
-)
+var completionMockCreated int64 = 10
 
 type chatCompletionRequest struct {
-	Model            string                 `json:"model" validate:"required"`
-	Messages         []chatMessage          `json:"messages" validate:"required,min=1"`
-	MaxTokens        int                    `json:"max_tokens,omitempty"`
-	FrequencyPenalty float64                `json:"frequency_penalty,omitempty"`
-	N                int                    `json:"n,omitempty"`
-	PresencePenalty  float64                `json:"presence_penalty,omitempty"`
-	Seed             int                    `json:"seed,omitempty"`
-	Stream           bool                   `json:"stream,omitempty"`
-	StreamOptions    *streamOptions         `json:"stream_options,omitempty"`
-	Temperature      float64                `json:"temperature,omitempty"`
-	TopP             float64                `json:"top_p,omitempty"`
-	Tools            []tool                 `json:"tools,omitempty"`
-	ToolChoice       *toolChoice            `json:"tool_choice,omitempty"`
-	User             string                 `json:"user,omitempty"`
-	Stop             []string               `json:"stop,omitempty"`
-	ResponseFormat   map[string]interface{} `json:"response_format,omitempty"`
+	Model             string                 `json:"model" validate:"required"`
+	Messages          []chatMessage          `json:"messages" validate:"required,min=1"`
+	MaxTokens         int                    `json:"max_tokens,omitempty"`
+	FrequencyPenalty  float64                `json:"frequency_penalty,omitempty"`
+	N                 int                    `json:"n,omitempty"`
+	PresencePenalty   float64                `json:"presence_penalty,omitempty"`
+	Seed              int                    `json:"seed,omitempty"`
+	Stream            bool                   `json:"stream,omitempty"`
+	StreamOptions     *streamOptions         `json:"stream_options,omitempty"`
+	Temperature       float64                `json:"temperature,omitempty"`
+	TopP              float64                `json:"top_p,omitempty"`
+	Tools             []tool                 `json:"tools,omitempty"`
+	ToolChoice        *toolChoice            `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool                  `json:"parallel_tool_calls,omitempty"`
+	User              string                 `json:"user,omitempty"`
+	Stop              stopSequences          `json:"stop,omitempty"`
+	ResponseFormat    map[string]interface{} `json:"response_format,omitempty"`
+}
+
+// mockTools returns the function tools of the request, restricted to the allowed ones if the
+// tool_choice lists them.
+func (r *chatCompletionRequest) mockTools() []mockTool {
+	allowed := map[string]bool{}
+	if r.ToolChoice != nil && r.ToolChoice.IsAllowedTools() {
+		for _, tool := range r.ToolChoice.AllowedTools.AllowedTools {
+			allowed[tool.Function.Name] = true
+		}
+	}
+	var tools []mockTool
+	for _, tool := range r.Tools {
+		if tool.Type != toolTypeFunction || (len(allowed) > 0 && !allowed[tool.Function.Name]) {
+			continue
+		}
+		tools = append(tools, mockTool{Name: tool.Function.Name, Parameters: tool.Function.Parameters})
+	}
+	return tools
+}
+
+// toolUse returns how the tool_choice and parallel_tool_calls of the request let the model use
+// its tools.
+func (r *chatCompletionRequest) toolUse() toolUse {
+	use := toolUse{Mode: toolAuto, Single: r.ParallelToolCalls != nil && !*r.ParallelToolCalls}
+	switch {
+	case r.ToolChoice == nil:
+	case r.ToolChoice.IsString():
+		use.Mode = r.ToolChoice.GetStringValue()
+	case r.ToolChoice.IsAllowedTools():
+		if r.ToolChoice.AllowedTools.Mode == toolRequired {
+			use.Mode = toolRequired
+		}
+	case r.ToolChoice.IsFunction():
+		use.Name = r.ToolChoice.FunctionChoice.Function.Name
+	}
+	return use
+}
+
+// stopSequences is the stop parameter, which is either a single string or a list of strings.
+type stopSequences []string
+
+func (s *stopSequences) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = stopSequences{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
 }
 
 type streamOptions struct {
@@ -81,6 +130,7 @@ type toolChoice struct {
 // allowedToolsChoice represents the allowed_tools configuration
 type allowedToolsChoice struct {
 	Type         string        `json:"type"`          // Always "allowed_tools"
+	Mode         string        `json:"mode"`          // "auto" or "required"
 	AllowedTools []allowedTool `json:"allowed_tools"` // Constrains the tools available to the model
 }
 
@@ -233,6 +283,9 @@ type usage struct {
 	TotalTokens      int `json:"total_tokens,omitempty"`
 }
 
+// nullContent is the content of a message carrying tool calls, which OpenAI sends as an explicit null.
+var nullContent = json.RawMessage("null")
+
 type chatMessage struct {
 	Name      string     `json:"name,omitempty"`
 	Role      string     `json:"role,omitempty"`
@@ -345,15 +398,16 @@ func (m *chatMessage) ParseContent() []messageContent {
 }
 
 type toolCall struct {
-	Index    int          `json:"index"`
-	Id       string       `json:"id"`
-	Type     string       `json:"type"`
+	// Index is only set in stream deltas.
+	Index    *int         `json:"index,omitempty"`
+	Id       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
 	Function functionCall `json:"function"`
 }
 
 type functionCall struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
+	Id        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
 	Arguments string `json:"arguments"`
 }
 
@@ -41,12 +41,7 @@ func (p *moonshotProvider) ShouldHandleRequest(ctx *gin.Context) bool {
 func (p *moonshotProvider) HandleChatCompletions(ctx *gin.Context) {
 	// The real moonshot API requires "Authorization: Bearer <api key>"; the error body mirrors the actual API response.
 	if !strings.HasPrefix(ctx.GetHeader("Authorization"), "Bearer ") {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"message": "Incorrect API key provided",
-				"type":    "incorrect_api_key_error",
-			},
-		})
+		p.sendMockError(ctx, http.StatusUnauthorized, "Incorrect API key provided")
 		return
 	}
 
@@ -54,12 +49,12 @@ func (p *moonshotProvider) HandleChatCompletions(ctx *gin.Context) {
 	if !bindAndValidateChatRequest(ctx, &chatRequest) {
 		return
 	}
-	response := prompt2Response(lastStringPrompt(&chatRequest))
+	response := prompt2Response(ctx, lastStringPrompt(&chatRequest))
 
 	if chatRequest.Stream {
 		p.handleStreamResponse(ctx, chatRequest, response)
 	} else {
-		ctx.JSON(http.StatusOK, createChatCompletionResponse(chatRequest.Model, response))
+		ctx.JSON(http.StatusOK, createChatCompletionResponse(ctx, chatRequest.Model, []mockChoice{currentChoice(ctx, response)}))
 	}
 }
 
@@ -73,6 +68,7 @@ func (p *moonshotProvider) handleStreamResponse(ctx *gin.Context, chatRequest ch
 		Created: completionMockCreated,
 		Model:   chatRequest.Model,
 	}
+	u := mockUsage(ctx, response)
 	go func() {
 		sendChunk := func(choice chatCompletionChoice) bool {
 			streamResponse.Choices = []chatCompletionChoice{choice}
@@ -91,23 +87,23 @@ func (p *moonshotProvider) handleStreamResponse(ctx *gin.Context, chatRequest ch
 			return
 		}
 
-		for _, s := range []rune(response) {
-			if !sendChunk(chatCompletionChoice{Delta: &chatMessage{Content: string(s)}}) {
+		for _, chunk := range streamChunks(ctx, response, splitRunes) {
+			if !sendChunk(chatCompletionChoice{Delta: &chatMessage{Content: chunk}}) {
 				return
 			}
 			// Simulate response delay; cancel promptly if the client disconnects
 			select {
 			case <-ctx.Request.Context().Done():
 				return
-			case <-time.After(50 * time.Millisecond):
+			case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
 			}
 		}
 
 		// Moonshot-specific: the stream ends with an empty-delta block whose usage is nested in choices[0].usage (the end data block shape of the real API).
 		if !sendChunk(chatCompletionChoice{
 			Delta:        &chatMessage{},
-			FinishReason: ptr(stopReason),
-			Usage:        &completionMockUsage,
+			FinishReason: ptr(finishReason(ctx, nil)),
+			Usage:        &u,
 		}) {
 			return
 		}
@@ -125,3 +121,30 @@ func (p *moonshotProvider) handleStreamResponse(ctx *gin.Context, chatRequest ch
 		}
 	})
 }
+
+func (p *moonshotProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	ctx.JSON(statusCode, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    moonshotErrorType(statusCode),
+		},
+	})
+}
+
+// moonshotErrorType returns the error type the real moonshot API pairs with the status code.
+func moonshotErrorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return "incorrect_api_key_error"
+	case http.StatusForbidden:
+		return "permission_denied_error"
+	case http.StatusNotFound:
+		return "resource_not_found_error"
+	case http.StatusTooManyRequests:
+		return "rate_limit_reached_error"
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "server_error"
+	}
+	return "invalid_request_error"
+}
@@ -18,20 +18,37 @@ func (p *openAiProvider) ShouldHandleRequest(ctx *gin.Context) bool {
 }
 
 func (p *openAiProvider) HandleChatCompletions(ctx *gin.Context) {
+	if ctx.Request.URL.Path == responsesPath {
+		p.handleResponses(ctx)
+		return
+	}
 	var chatRequest chatCompletionRequest
 	if !bindAndValidateChatRequest(ctx, &chatRequest) {
 		return
 	}
-	response := prompt2Response(lastStringPrompt(&chatRequest))
+	if err := validateToolUse(chatRequest.mockTools(), chatRequest.toolUse()); err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	prompt := lastStringPrompt(&chatRequest)
+	afterToolResult := chatRequest.Messages[len(chatRequest.Messages)-1].Role == roleTool
+	var choices []mockChoice
+	if calls := mockToolCalls(chatRequest.mockTools(), chatRequest.toolUse(), prompt, afterToolResult); len(calls) > 0 {
+		choices = toolChoices(ctx, chatRequest.N, calls)
+	} else {
+		choices = responseChoices(ctx, chatRequest.N, func(index int) string {
+			return choiceResponse(ctx, prompt, index)
+		})
+	}
 
 	if chatRequest.Stream {
-		p.handleStreamResponse(ctx, chatRequest, response)
+		p.handleStreamResponse(ctx, chatRequest, choices)
 	} else {
-		p.handleNonStreamResponse(ctx, chatRequest, response)
+		p.handleNonStreamResponse(ctx, chatRequest, choices)
 	}
 }
 
-func (p *openAiProvider) handleStreamResponse(ctx *gin.Context, chatRequest chatCompletionRequest, response string) {
+func (p *openAiProvider) handleStreamResponse(ctx *gin.Context, chatRequest chatCompletionRequest, choices []mockChoice) {
 	utils.SetEventStreamHeaders(ctx)
 	dataChan := make(chan string)
 	stopChan := make(chan bool, 1)
@@ -41,24 +58,50 @@ func (p *openAiProvider) handleStreamResponse(ctx *gin.Context, chatRequest chat
 		Created: completionMockCreated,
 		Model:   chatRequest.Model,
 	}
+	u := mockUsage(ctx, choiceTexts(choices)...)
 	go func() {
-		responseRunes := []rune(response)
-		for i, s := range responseRunes {
-			choice := chatCompletionChoice{Delta: &chatMessage{Content: string(s)}}
-			if i == len(responseRunes)-1 {
-				choice.FinishReason = ptr(stopReason)
+		// Every chunk carries the delta of a single choice, and the choices are interleaved like
+		// the real API does when n > 1.
+		deltas := make([][]*chatMessage, len(choices))
+		steps := 0
+		for i, choice := range choices {
+			deltas[i] = choiceDeltas(ctx, choice)
+			if len(deltas[i]) > steps {
+				steps = len(deltas[i])
 			}
-			streamResponse.Choices = []chatCompletionChoice{choice}
+		}
+		for step := 0; step < steps; step++ {
+			for i, choice := range choices {
+				if step >= len(deltas[i]) {
+					continue
+				}
+				delta := chatCompletionChoice{Index: choice.Index, Delta: deltas[i][step]}
+				if step == len(deltas[i])-1 {
+					delta.FinishReason = ptr(choice.finishReason(nil))
+				}
+				streamResponse.Choices = []chatCompletionChoice{delta}
+				jsonStr, _ := json.Marshal(streamResponse)
+				select {
+				case dataChan <- string(jsonStr):
+				case <-ctx.Request.Context().Done():
+					// client gone; stop producing to avoid leaking this goroutine
+					return
+				}
+			}
+
+			// Simulate response delay
+			time.Sleep(chunkDelay(ctx, 200*time.Millisecond))
+		}
+		// With stream_options.include_usage the usage arrives in a last chunk without choices.
+		if chatRequest.StreamOptions != nil && chatRequest.StreamOptions.IncludeUsage {
+			streamResponse.Choices = []chatCompletionChoice{}
+			streamResponse.Usage = &u
 			jsonStr, _ := json.Marshal(streamResponse)
 			select {
 			case dataChan <- string(jsonStr):
 			case <-ctx.Request.Context().Done():
-				// client gone; stop producing to avoid leaking this goroutine
 				return
 			}
-
-			// Simulate response delay
-			time.Sleep(200 * time.Millisecond)
 		}
 		stopChan <- true
 	}()
@@ -75,27 +118,67 @@ func (p *openAiProvider) handleStreamResponse(ctx *gin.Context, chatRequest chat
 	})
 }
 
-func (p *openAiProvider) handleNonStreamResponse(ctx *gin.Context, chatRequest chatCompletionRequest, response string) {
-	completion := createChatCompletionResponse(chatRequest.Model, response)
+// choiceDeltas returns the stream deltas of a choice: its reply one character at a time, or its
+// tool calls, each opened with its id and name and followed by fragments of its arguments, then an
+// empty delta for the finish reason.
+func choiceDeltas(ctx *gin.Context, choice mockChoice) []*chatMessage {
+	var deltas []*chatMessage
+	if len(choice.ToolCalls) == 0 {
+		for _, chunk := range streamChunks(ctx, choice.Text, splitRunes) {
+			deltas = append(deltas, &chatMessage{Content: chunk})
+		}
+		if len(deltas) == 0 {
+			deltas = append(deltas, &chatMessage{Content: ""})
+		}
+		return deltas
+	}
+	for _, call := range choice.ToolCalls {
+		deltas = append(deltas, &chatMessage{Role: roleAssistant, ToolCalls: []toolCall{{
+			Index:    ptr(call.Index),
+			Id:       call.id("call"),
+			Type:     toolTypeFunction,
+			Function: functionCall{Name: call.Name},
+		}}})
+		for _, fragment := range splitArguments(call.argumentsJSON()) {
+			deltas = append(deltas, &chatMessage{ToolCalls: []toolCall{{
+				Index:    ptr(call.Index),
+				Function: functionCall{Arguments: fragment},
+			}}})
+		}
+	}
+	return append(deltas, &chatMessage{})
+}
+
+func (p *openAiProvider) handleNonStreamResponse(ctx *gin.Context, chatRequest chatCompletionRequest, choices []mockChoice) {
+	completion := createChatCompletionResponse(ctx, chatRequest.Model, choices)
 	ctx.JSON(http.StatusOK, completion)
 }
 
-func createChatCompletionResponse(model, response string) chatCompletionResponse {
+func createChatCompletionResponse(ctx *gin.Context, model string, choices []mockChoice) chatCompletionResponse {
+	u := mockUsage(ctx, choiceTexts(choices)...)
+	completionChoices := make([]chatCompletionChoice, 0, len(choices))
+	for _, choice := range choices {
+		message := &chatMessage{Role: roleAssistant, Content: choice.Text}
+		for _, call := range choice.ToolCalls {
+			message.Content = nullContent
+			message.ToolCalls = append(message.ToolCalls, toolCall{
+				Id:       call.id("call"),
+				Type:     toolTypeFunction,
+				Function: functionCall{Name: call.Name, Arguments: call.argumentsJSON()},
+			})
+		}
+		completionChoices = append(completionChoices, chatCompletionChoice{
+			Index:        choice.Index,
+			Message:      message,
+			FinishReason: ptr(choice.finishReason(nil)),
+		})
+	}
 	return chatCompletionResponse{
 		Id:      completionMockId,
 		Object:  objectChatCompletion,
 		Created: completionMockCreated,
 		Model:   model,
-		Choices: []chatCompletionChoice{
-			{
-				Index: 0,
-				Message: &chatMessage{
-					Role:    roleAssistant,
-					Content: response,
-				},
-				FinishReason: ptr(stopReason),
-			},
-		},
-		Usage: &completionMockUsage,
+		Choices: completionChoices,
+		Usage:   &u,
 	}
 }
@@ -7,8 +7,16 @@ import (
 	"net/http"
 	"strings"
 
+	"llm-mock-server/pkg/apikey"
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/fault"
+	"llm-mock-server/pkg/journal"
 	"llm-mock-server/pkg/log"
+	"llm-mock-server/pkg/middleware"
 	"llm-mock-server/pkg/provider"
+	"llm-mock-server/pkg/ratelimit"
+	"llm-mock-server/pkg/stub"
+	"llm-mock-server/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +28,9 @@ type requestHandler interface {
 }
 
 var (
+	// mockConfig is the declarative mock behaviour passed to SetupRoutes.
+	mockConfig *config.Config
+
 	orderedChatCompletionsHandlers = []struct {
 		name    string
 		handler requestHandler
@@ -60,6 +71,7 @@ var (
 		"/v1/text/chatcompletion_pro",
 		// openai
 		"/v1/chat/completions",
+		"/v1/responses",
 		// qwen
 		"/compatible-mode/v1/chat/completions",
 		"/api/v1/services/aigc/text-generation/generation",
@@ -91,49 +103,72 @@ var (
 )
 
 // SetupRoutes 支持按provider类型配置不同的路由
-func SetupRoutes(server *gin.Engine, providerType string) {
+// responses 保存 Responses API 的响应，为 nil 时使用默认大小的新存储
+// modelActions 在 Gemini、Vertex 的模型路由上先于对话处理执行，用于处理 :embedContent 等非生成动作
+func SetupRoutes(server *gin.Engine, providerType string, cfg *config.Config, responses *ResponseStore, modelActions ...gin.HandlerFunc) {
+	mockConfig = cfg
+	if responses == nil {
+		responses = NewResponseStore(DefaultResponseStoreSize)
+	}
+	// The routes below find the response store of this server in the request context.
+	group := server.Group("", func(ctx *gin.Context) {
+		ctx.Set(responseStoreKey, responses)
+	})
 	// 根据provider类型配置对应的路由
 	switch strings.ToLower(providerType) {
 	case "minimax":
-		server.POST("/v1/text/chatcompletion_v2", chatCompletionsHandlers["openai"].HandleChatCompletions)
-		server.POST("/v1/text/chatcompletion_pro", chatCompletionsHandlers["minimax"].HandleChatCompletions)
+		group.POST("/v1/text/chatcompletion_v2", handleWith("openai"))
+		group.POST("/v1/text/chatcompletion_pro", handleWith("minimax"))
 	case "dify":
-		server.POST("/v1/completion-messages", chatCompletionsHandlers["dify"].HandleChatCompletions)
-		server.POST("/v1/chat-messages", chatCompletionsHandlers["dify"].HandleChatCompletions)
+		group.POST("/v1/completion-messages", handleWith("dify"))
+		group.POST("/v1/chat-messages", handleWith("dify"))
 	case "qwen":
-		server.POST("/compatible-mode/v1/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
-		server.POST("/api/v1/services/aigc/text-generation/generation", chatCompletionsHandlers["qwen"].HandleChatCompletions)
+		group.POST("/compatible-mode/v1/chat/completions", handleWith("openai"))
+		group.POST("/api/v1/services/aigc/text-generation/generation", handleWith("qwen"))
 	case "gemini":
-		server.POST("/v1beta/models/:modelAndAction", chatCompletionsHandlers["gemini"].HandleChatCompletions)
+		group.POST("/v1beta/models/:modelAndAction", withModelActions(modelActions, handleWith("gemini"))...)
 	case "vertex":
-		server.POST("/v1/publishers/google/models/:modelAndAction", chatCompletionsHandlers["vertex"].HandleChatCompletions)
-		server.POST("/v1/projects/:project/locations/:location/publishers/google/models/:modelAndAction", chatCompletionsHandlers["vertex"].HandleChatCompletions)
+		group.POST("/v1/publishers/google/models/:modelAndAction", withModelActions(modelActions, handleWith("vertex"))...)
+		group.POST("/v1/projects/:project/locations/:location/publishers/google/models/:modelAndAction", withModelActions(modelActions, handleWith("vertex"))...)
 	case "bedrock":
-		server.POST("/model/:modelId/converse", chatCompletionsHandlers["bedrock"].HandleChatCompletions)
-		server.POST("/model/:modelId/converse-stream", chatCompletionsHandlers["bedrock"].HandleChatCompletions)
+		group.POST("/model/:modelId/converse", handleWith("bedrock"))
+		group.POST("/model/:modelId/converse-stream", handleWith("bedrock"))
 	case "doubao":
-		server.POST("/api/v3/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/api/v3/chat/completions", handleWith("openai"))
 	case "baidu":
-		server.POST("/v2/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/v2/chat/completions", handleWith("openai"))
 	case "zhipu":
-		server.POST("/api/paas/v4/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/api/paas/v4/chat/completions", handleWith("openai"))
 	case "github":
-		server.POST("/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/chat/completions", handleWith("openai"))
 	case "groq":
-		server.POST("/openai/v1/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/openai/v1/chat/completions", handleWith("openai"))
 	case "cloudflare":
-		server.POST("/client/v4/accounts/:accountId/ai/v1/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/client/v4/accounts/:accountId/ai/v1/chat/completions", handleWith("openai"))
 	// 其他 cases...
 	case "moonshot":
-		server.POST("/v1/chat/completions", chatCompletionsHandlers["moonshot"].HandleChatCompletions)
+		group.POST("/v1/chat/completions", handleWith("moonshot"))
 	case "openai", "ai360", "deepseek", "together", "baichuan", "yi", "stepfun":
 		// 这些provider都使用OpenAI兼容的格式，调用openAiProvider
-		server.POST("/v1/chat/completions", chatCompletionsHandlers["openai"].HandleChatCompletions)
+		group.POST("/v1/chat/completions", handleWith("openai"))
+		if strings.EqualFold(providerType, "openai") {
+			group.POST(responsesPath, handleWith("openai"))
+			setupResponseRoutes(group, responses)
+		}
 	default:
-		// 未知的provider类型，启用所有路由
-		for _, route := range chatCompletionsRoutes {
-			server.POST(route, handleChatCompletions)
+		// 未知的provider类型，启用所有路由；配置文件中声明的路由优先
+		routes := chatCompletionsRoutes
+		if cfg != nil && len(cfg.Routes) > 0 {
+			routes = cfg.Routes
+		}
+		for _, route := range routes {
+			if strings.HasSuffix(route, "/:modelAndAction") {
+				group.POST(route, withModelActions(modelActions, handleChatCompletions)...)
+			} else {
+				group.POST(route, handleChatCompletions)
+			}
 		}
+		setupResponseRoutes(group, responses)
 		if providerType != "" {
 			log.Warnf("Unknown provider type: %s, enabled all routes", providerType)
 		} else {
@@ -142,23 +177,155 @@ func SetupRoutes(server *gin.Engine, providerType string) {
 	}
 }
 
+// withModelActions returns the handlers of a model route: the model actions, then the chat handler.
+func withModelActions(modelActions []gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, 0, len(modelActions)+1)
+	return append(append(handlers, modelActions...), handler)
+}
+
 func handleChatCompletions(context *gin.Context) {
 	if err := buildRequestContext(context); err != nil {
 		return
 	}
 	for _, entry := range orderedChatCompletionsHandlers {
 		if entry.handler.ShouldHandleRequest(context) {
-			entry.handler.HandleChatCompletions(context)
+			serveChatCompletions(context, entry.name, entry.handler)
 			return
 		}
 	}
 	context.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
 }
 
+// handleWith returns a gin handler that always dispatches to the named provider.
+func handleWith(name string) gin.HandlerFunc {
+	handler := chatCompletionsHandlers[name]
+	return func(context *gin.Context) {
+		if err := buildRequestContext(context); err != nil {
+			return
+		}
+		serveChatCompletions(context, name, handler)
+	}
+}
+
+// serveChatCompletions applies the configured behaviour of the provider (enabled, accepted models,
+// API keys, rate limits, latency and fault injection) before handing the request over to the
+// provider itself.
+func serveChatCompletions(context *gin.Context, name string, handler requestHandler) {
+	requestCtx, _ := getRequestContext(context)
+	context.Set(journal.ProviderKey, name)
+	providerConfig := mockConfig.Provider(name)
+	directive, err := parseMockDirective(requestCtx.Prompt)
+	if err != nil {
+		sendMockError(context, handler, http.StatusBadRequest, err.Error())
+		return
+	}
+	if legacy := legacyAuthError(name, requestCtx.Prompt); legacy != nil {
+		directive = legacy
+	}
+	// A prompt directive overrides the control headers.
+	scenario := middleware.GetScenario(context).Merge(directive)
+	// Stubs registered through the admin API take precedence over the built-in provider mocks.
+	if s := stub.DefaultStore.Match(stub.Request{
+		Provider: name,
+		Path:     requestCtx.Path,
+		Model:    requestCtx.Model,
+		Prompt:   requestCtx.Prompt,
+		Header:   context.Request.Header,
+	}); s != nil {
+		context.Set(journal.StubKey, s.Id)
+		if injectFaults(context, handler, providerConfig, scenario) {
+			stub.Serve(context, s)
+		}
+		return
+	}
+
+	if !providerConfig.IsEnabled() {
+		context.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	behavior := newMockBehavior(providerConfig, requestCtx)
+	behavior.apply(scenario)
+	context.Set(mockBehaviorKey, behavior)
+
+	if !providerConfig.AcceptsModel(requestCtx.Model) {
+		sendMockError(context, handler, http.StatusNotFound, fmt.Sprintf("The model `%s` does not exist", requestCtx.Model))
+		return
+	}
+
+	key := utils.APIKey(context.Request)
+	if rejection := apikey.DefaultRegistry.Check(key, requestCtx.Model); rejection != "" {
+		sendAPIKeyError(context, handler, requestCtx.Model, rejection)
+		return
+	}
+
+	// Budgets are kept per API key and model, so that key rotation can be exercised.
+	limitKey := name + "|" + key + "|" + requestCtx.Model
+	limits := mockConfig.RateLimitOf(name)
+	status := ratelimit.DefaultLimiter.Acquire(limitKey, limits)
+	setRateLimitHeaders(context, handler, status)
+	if status.Exceeded != "" {
+		sendRateLimitError(context, handler, requestCtx.Model, status)
+		return
+	}
+
+	if !sleepContext(context, behavior.Latency) {
+		return
+	}
+	if injectFaults(context, handler, providerConfig, scenario) {
+		handler.HandleChatCompletions(context)
+		if behavior.reported != nil {
+			ratelimit.DefaultLimiter.Charge(limitKey, limits, behavior.reported.TotalTokens)
+		}
+	}
+}
+
+// injectFaults rolls the global, provider and per-request (scenario) faults and applies the ones
+// that fire. It reports false if the request was answered (or its connection reset) by a fault.
+func injectFaults(context *gin.Context, handler requestHandler, providerConfig *config.ProviderConfig, scenario *config.Scenario) bool {
+	var faults *config.FaultConfig
+	if mockConfig != nil {
+		faults = mockConfig.Faults
+	}
+	plan := fault.Decide(faults.Merge(providerConfig.FaultConfig()).Merge(scenario.FaultConfig()))
+
+	if !sleepContext(context, plan.Delay) {
+		return false
+	}
+	if plan.ResetBeforeHeaders {
+		log.Infof("fault injected: connection reset before headers")
+		context.Abort()
+		fault.Reset(context.Writer)
+		return false
+	}
+	if plan.Error != nil {
+		message := plan.Error.Message
+		if message == "" {
+			message = http.StatusText(plan.Error.Status)
+		}
+		if message == "" {
+			message = fmt.Sprintf("HTTP %d", plan.Error.Status)
+		}
+		sendMockError(context, handler, plan.Error.Status, message)
+		return false
+	}
+	if plan.CorruptsBody() {
+		fault.Wrap(context, plan)
+	}
+	return true
+}
+
 type requestContext struct {
 	Host  string
 	Path  string
 	Model string
+	// Prompt is the text of the last user message, whatever the provider's request shape.
+	Prompt string
+	// Messages holds the text of every message of the request, system instructions included.
+	Messages []string
+	// MaxTokens is the maximum number of completion tokens requested, or zero if there is none.
+	MaxTokens int
+	// Stop holds the stop sequences of the request.
+	Stop []string
 }
 
 func buildRequestContext(context *gin.Context) error {
@@ -179,11 +346,18 @@ func buildRequestContext(context *gin.Context) error {
 		return err
 	}
 	model, _ := data["model"].(string)
+	if model == "" {
+		model = modelFromRequest(context, data)
+	}
 
 	context.Set("requestContext", requestContext{
-		Host:  context.Request.Host,
-		Path:  context.Request.URL.Path,
-		Model: model})
+		Host:      context.Request.Host,
+		Path:      context.Request.URL.Path,
+		Model:     model,
+		Prompt:    promptFromRequest(data),
+		Messages:  messagesFromRequest(data),
+		MaxTokens: maxTokensFromRequest(data),
+		Stop:      stopFromRequest(data)})
 
 	return nil
 }
@@ -201,3 +375,224 @@ func getRequestContext(context *gin.Context) (requestContext, error) {
 
 	return ctx, nil
 }
+
+// modelFromRequest resolves the model of providers that do not carry it in the "model" body field:
+// gemini/vertex/bedrock put it in the path and hunyuan uses the capitalized "Model" key.
+func modelFromRequest(context *gin.Context, data map[string]interface{}) string {
+	if modelId := context.Param("modelId"); modelId != "" {
+		return modelId
+	}
+	if modelAndAction := context.Param("modelAndAction"); modelAndAction != "" {
+		return strings.SplitN(modelAndAction, ":", 2)[0]
+	}
+	model, _ := data["Model"].(string)
+	return model
+}
+
+// promptFromRequest returns the text of the last user message across the request shapes of all providers.
+func promptFromRequest(data map[string]interface{}) string {
+	// openai-compatible / claude / bedrock / minimax
+	if messages, ok := data["messages"].([]interface{}); ok {
+		return lastUserText(messages)
+	}
+	// qwen
+	if input, ok := data["input"].(map[string]interface{}); ok {
+		if messages, ok := input["messages"].([]interface{}); ok {
+			return lastUserText(messages)
+		}
+		return textOf(input["prompt"])
+	}
+	// openai responses
+	switch input := data["input"].(type) {
+	case string:
+		return input
+	case []interface{}:
+		return lastUserText(input)
+	}
+	// gemini / vertex
+	if contents, ok := data["contents"].([]interface{}); ok {
+		return lastUserText(contents)
+	}
+	// hunyuan
+	if messages, ok := data["Messages"].([]interface{}); ok {
+		return lastUserText(messages)
+	}
+	// cohere
+	if message, ok := data["message"].(string); ok {
+		return message
+	}
+	// dify
+	if query, ok := data["query"].(string); ok {
+		return query
+	}
+	// deepl
+	if texts, ok := data["text"].([]interface{}); ok && len(texts) > 0 {
+		return textOf(texts[len(texts)-1])
+	}
+	return ""
+}
+
+// maxTokensFromRequest returns the completion token limit across the request shapes of all providers.
+func maxTokensFromRequest(data map[string]interface{}) int {
+	// openai-compatible / openai responses / claude / cohere / minimax
+	for _, key := range []string{"max_completion_tokens", "max_output_tokens", "max_tokens", "tokens_to_generate"} {
+		if value, ok := data[key].(float64); ok {
+			return int(value)
+		}
+	}
+	// gemini / vertex, bedrock, qwen
+	for _, path := range [][2]string{{"generationConfig", "maxOutputTokens"}, {"inferenceConfig", "maxTokens"}, {"parameters", "max_tokens"}} {
+		if parent, ok := data[path[0]].(map[string]interface{}); ok {
+			if value, ok := parent[path[1]].(float64); ok {
+				return int(value)
+			}
+		}
+	}
+	return 0
+}
+
+// stopFromRequest returns the stop sequences across the request shapes of all providers.
+func stopFromRequest(data map[string]interface{}) []string {
+	// openai-compatible / claude / cohere
+	for _, key := range []string{"stop", "stop_sequences"} {
+		if value, ok := data[key]; ok {
+			return stringsOf(value)
+		}
+	}
+	// gemini / vertex, bedrock, qwen
+	for _, path := range [][2]string{{"generationConfig", "stopSequences"}, {"inferenceConfig", "stopSequences"}, {"parameters", "stop"}} {
+		if parent, ok := data[path[0]].(map[string]interface{}); ok {
+			if value, ok := parent[path[1]]; ok {
+				return stringsOf(value)
+			}
+		}
+	}
+	return nil
+}
+
+// stringsOf returns a string or a list of strings as a list.
+func stringsOf(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// messagesFromRequest returns the text of every message, system instructions included, across the
+// request shapes of all providers.
+func messagesFromRequest(data map[string]interface{}) []string {
+	var messages []string
+	add := func(text string) {
+		if text != "" {
+			messages = append(messages, text)
+		}
+	}
+	// claude / bedrock / cohere / gemini / vertex / openai responses system instructions
+	for _, key := range []string{"system", "preamble", "systemInstruction", "system_instruction", "instructions"} {
+		if value, ok := data[key]; ok {
+			if instruction, ok := value.(map[string]interface{}); ok && instruction["parts"] != nil {
+				value = instruction["parts"]
+			}
+			add(textOf(value))
+		}
+	}
+	lists := []interface{}{data["messages"], data["contents"], data["Messages"], data["chat_history"]}
+	switch input := data["input"].(type) {
+	case map[string]interface{}:
+		// qwen
+		lists = append(lists, input["messages"])
+		add(textOf(input["prompt"]))
+	case string:
+		// openai responses
+		add(input)
+	case []interface{}:
+		// openai responses
+		lists = append(lists, input)
+	}
+	for _, list := range lists {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			if message, ok := item.(map[string]interface{}); ok {
+				text := messageText(message)
+				if text == "" {
+					// cohere chat_history entries
+					text, _ = message["message"].(string)
+				}
+				add(text)
+			}
+		}
+	}
+	// cohere / dify
+	for _, key := range []string{"message", "query"} {
+		if text, ok := data[key].(string); ok {
+			add(text)
+		}
+	}
+	// deepl
+	if texts, ok := data["text"].([]interface{}); ok {
+		for _, text := range texts {
+			add(textOf(text))
+		}
+	}
+	return messages
+}
+
+// lastUserText returns the text of the last message sent by the user, falling back to the last message.
+func lastUserText(messages []interface{}) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		message, ok := messages[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"role", "Role", "sender_type"} {
+			if role, ok := message[key].(string); ok && strings.EqualFold(role, "user") {
+				return messageText(message)
+			}
+		}
+	}
+	if message, ok := messages[len(messages)-1].(map[string]interface{}); ok {
+		return messageText(message)
+	}
+	return ""
+}
+
+func messageText(message map[string]interface{}) string {
+	for _, key := range []string{"content", "Content", "parts", "text"} {
+		if value, ok := message[key]; ok {
+			return textOf(value)
+		}
+	}
+	return ""
+}
+
+// textOf flattens a string, a text part ({"text": ...}) or a list of parts into plain text.
+func textOf(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		text, _ := v["text"].(string)
+		return text
+	case []interface{}:
+		var texts []string
+		for _, item := range v {
+			if text := textOf(item); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
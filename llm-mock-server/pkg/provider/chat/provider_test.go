@@ -0,0 +1,239 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxTokensFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "openai", body: `{"max_tokens": 16}`, expected: 16},
+		{name: "openai reasoning", body: `{"max_completion_tokens": 32, "max_tokens": 16}`, expected: 32},
+		{name: "minimax", body: `{"tokens_to_generate": 8}`, expected: 8},
+		{name: "gemini", body: `{"generationConfig": {"maxOutputTokens": 64}}`, expected: 64},
+		{name: "bedrock", body: `{"inferenceConfig": {"maxTokens": 128}}`, expected: 128},
+		{name: "qwen", body: `{"parameters": {"max_tokens": 256}}`, expected: 256},
+		{name: "none", body: `{"model": "gpt-4o"}`, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.body), &data); err != nil {
+				t.Fatal(err)
+			}
+			if got := maxTokensFromRequest(data); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStopFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{name: "openai string", body: `{"stop": "\n"}`, expected: []string{"\n"}},
+		{name: "openai list", body: `{"stop": ["a", "b"]}`, expected: []string{"a", "b"}},
+		{name: "claude", body: `{"stop_sequences": ["END"]}`, expected: []string{"END"}},
+		{name: "gemini", body: `{"generationConfig": {"stopSequences": ["x"]}}`, expected: []string{"x"}},
+		{name: "bedrock", body: `{"inferenceConfig": {"stopSequences": ["y"]}}`, expected: []string{"y"}},
+		{name: "qwen", body: `{"parameters": {"stop": "z"}}`, expected: []string{"z"}},
+		{name: "none", body: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.body), &data); err != nil {
+				t.Fatal(err)
+			}
+			if got := stopFromRequest(data); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServeWithoutConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	// Without --config every provider has no config entry.
+	SetupRoutes(server, "", nil, nil)
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "openai", path: "/v1/chat/completions", body: `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`},
+		{name: "claude", path: "/v1/messages", body: `{"model": "claude-3-5-sonnet", "max_tokens": 16, "messages": [{"role": "user", "content": "hello"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			if recorder.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGeminiStreamEmptyReply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	SetupRoutes(server, "", nil, nil)
+	// The stop sequence cuts the reply before its first word.
+	body := `{"contents": [{"role": "user", "parts": [{"text": "hi"}]}], "generationConfig": {"stopSequences": ["This"]}}`
+	request := httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.0-flash:streamGenerateContent?alt=sse&key=test", strings.NewReader(body))
+	request.Host = geminiDomain
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"finishReason":"STOP"`) {
+		t.Errorf("Expected a chunk with the finish reason, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestResponseChoices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	SetupRoutes(server, "", nil, nil)
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		model     string
+		maxTokens int
+		expected  []string
+	}{
+		{
+			name:     "openai n",
+			path:     "/v1/chat/completions",
+			body:     `{"model": "gpt-4o", "n": 3, "messages": [{"role": "user", "content": "hello world"}]}`,
+			model:    "gpt-4o",
+			expected: []string{"hello world", "hello world [1]", "hello world [2]"},
+		},
+		{
+			name:      "openai n within max_tokens",
+			path:      "/v1/chat/completions",
+			body:      `{"model": "gpt-4o", "n": 2, "max_tokens": 10, "messages": [{"role": "user", "content": "hello world"}]}`,
+			model:     "gpt-4o",
+			maxTokens: 10,
+			expected:  []string{"hello world", "hello world [1]"},
+		},
+		{
+			name:      "openai n without room for the number",
+			path:      "/v1/chat/completions",
+			body:      `{"model": "gpt-4o", "n": 2, "max_tokens": 1, "messages": [{"role": "user", "content": "hello world"}]}`,
+			model:     "gpt-4o",
+			maxTokens: 1,
+			expected:  []string{"hello", "hello"},
+		},
+		{
+			name:     "gemini candidateCount",
+			path:     "/v1beta/models/gemini-2.0-flash:generateContent?key=test",
+			body:     `{"contents": [{"role": "user", "parts": [{"text": "hi"}]}], "generationConfig": {"candidateCount": 2}}`,
+			model:    "gemini-2.0-flash",
+			expected: []string{"This is a mock response from Gemini provider. You said: hi", "This is a mock response from Gemini provider. You said: hi [1]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", "application/json")
+			if strings.HasPrefix(tt.path, "/v1beta/") {
+				request.Host = geminiDomain
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			var response struct {
+				Choices []struct {
+					Message struct {
+						Content string `json:"content"`
+					} `json:"message"`
+				} `json:"choices"`
+				Candidates []struct {
+					Content struct {
+						Parts []struct {
+							Text string `json:"text"`
+						} `json:"parts"`
+					} `json:"content"`
+				} `json:"candidates"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response %s: %v", recorder.Body.String(), err)
+			}
+			var contents []string
+			for _, choice := range response.Choices {
+				contents = append(contents, choice.Message.Content)
+			}
+			for _, candidate := range response.Candidates {
+				contents = append(contents, candidate.Content.Parts[0].Text)
+			}
+			if !reflect.DeepEqual(contents, tt.expected) {
+				t.Errorf("Expected choices %q, got %q", tt.expected, contents)
+			}
+			for _, content := range contents {
+				if count := tokenizer.ForModel(tt.model).Count(content); tt.maxTokens > 0 && count > tt.maxTokens {
+					t.Errorf("Expected %q to stay within %d tokens, got %d", content, tt.maxTokens, count)
+				}
+			}
+		})
+	}
+}
+
+func TestModelActionsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The model action answers the embedContent requests and lets the others through.
+	action := func(ctx *gin.Context) {
+		if strings.HasSuffix(ctx.Request.URL.Path, ":embedContent") {
+			ctx.String(http.StatusOK, "action")
+			ctx.Abort()
+		}
+	}
+	tests := []struct {
+		name         string
+		providerType string
+		path         string
+		action       bool
+	}{
+		{name: "all routes", path: "/v1beta/models/text-embedding-004:embedContent", action: true},
+		{name: "gemini", providerType: "gemini", path: "/v1beta/models/text-embedding-004:embedContent", action: true},
+		{name: "vertex", providerType: "vertex", path: "/v1/publishers/google/models/text-embedding-005:embedContent", action: true},
+		{name: "generation", providerType: "gemini", path: "/v1beta/models/gemini-2.0-flash:generateContent"},
+		{name: "other provider", providerType: "openai", path: "/v1beta/models/text-embedding-004:embedContent"},
+		{name: "other route", path: "/v1/models/text-embedding-004:embedContent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := gin.New()
+			SetupRoutes(server, tt.providerType, nil, nil, action)
+			body := `{"contents": [{"role": "user", "parts": [{"text": "hi"}]}]}`
+			request := httptest.NewRequest(http.MethodPost, tt.path+"?key=test", strings.NewReader(body))
+			request.Host = geminiDomain
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			if served := recorder.Body.String() == "action"; served != tt.action {
+				t.Errorf("Expected the model action to serve it: %t, got %d %s", tt.action, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
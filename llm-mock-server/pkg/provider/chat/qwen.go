@@ -1,8 +1,10 @@
 package chat
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -13,6 +15,8 @@ const (
 	qwenDomain              = "dashscope.aliyuncs.com"
 	qwenChatCompletionPath  = "/api/v1/services/aigc/text-generation/generation"
 	qwenResultFormatMessage = "message"
+	// qwenFinishNull is the finish reason DashScope reports while the stream is not finished.
+	qwenFinishNull = "null"
 )
 
 type qwenProvider struct {
@@ -58,13 +62,13 @@ func (p *qwenProvider) HandleChatCompletions(ctx *gin.Context) {
 	if messages[len(messages)-1].IsStringContent() {
 		prompt = messages[len(messages)-1].StringContent()
 	}
-	response := prompt2Response(prompt)
+	response := prompt2Response(ctx, prompt)
 
 	// Determine if the request is a stream request
 	isStream := p.isStreamRequest(ctx)
 
 	if isStream {
-		// todo stream response
+		p.handleStreamResponse(ctx, chatRequest, response)
 	} else {
 		p.handleNonStreamResponse(ctx, chatRequest, response)
 	}
@@ -79,6 +83,27 @@ func (p *qwenProvider) sendErrorResponse(ctx *gin.Context, statusCode int, error
 	ctx.JSON(statusCode, errorResp)
 }
 
+func (p *qwenProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	p.sendErrorResponse(ctx, statusCode, qwenErrorCode(statusCode), message)
+}
+
+// qwenErrorCode returns the error code the real DashScope API pairs with the status code.
+func qwenErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "InvalidParameter"
+	case http.StatusUnauthorized:
+		return "InvalidApiKey"
+	case http.StatusForbidden:
+		return "AccessDenied"
+	case http.StatusNotFound:
+		return "ModelNotFound"
+	case http.StatusTooManyRequests:
+		return "Throttling"
+	}
+	return "InternalError"
+}
+
 // isStreamRequest checks if the request is a stream request.
 func (p *qwenProvider) isStreamRequest(ctx *gin.Context) bool {
 	acceptHeader := ctx.GetHeader("Accept")
@@ -92,10 +117,54 @@ func (p *qwenProvider) isStreamRequest(ctx *gin.Context) bool {
 }
 
 func (p *qwenProvider) handleNonStreamResponse(ctx *gin.Context, chatRequest qwenTextGenRequest, response string) {
-	completion := createQwenTextGenResponse(chatRequest, response)
+	completion := createQwenTextGenResponse(ctx, chatRequest, response)
 	ctx.JSON(http.StatusOK, completion)
 }
 
+// handleStreamResponse streams the reply in the DashScope SSE framing: every event carries an id,
+// the result event type, the HTTP status and a data line with the output so far and the usage so
+// far. The output is the new text only with parameters.incremental_output, and the whole text
+// generated so far otherwise. Events before the last one report the finish reason "null".
+func (p *qwenProvider) handleStreamResponse(ctx *gin.Context, chatRequest qwenTextGenRequest, response string) {
+	utils.SetEventStreamHeaders(ctx)
+	chunks := streamChunks(ctx, response, splitRunes)
+	if len(chunks) == 0 {
+		chunks = []string{""}
+	}
+	generated := ""
+	for i, chunk := range chunks {
+		generated += chunk
+		text, finish := generated, qwenFinishNull
+		if chatRequest.Parameters.IncrementalOutput {
+			text = chunk
+		}
+		if i == len(chunks)-1 {
+			finish = finishReason(ctx, nil)
+		}
+		frame := qwenTextGenResponse{
+			Output:    qwenOutput(chatRequest, text, finish),
+			Usage:     qwenUsageOf(mockUsage(ctx, generated)),
+			RequestId: completionMockId,
+		}
+		data, _ := json.Marshal(frame)
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		default:
+		}
+		// Written raw like the Anthropic events: the DashScope framing is not the plain data-only
+		// SSE that streamEvent renders.
+		ctx.Writer.Write([]byte(fmt.Sprintf("id:%d\nevent:result\n:HTTP_STATUS/200\ndata:%s\n\n", i+1, data)))
+		ctx.Writer.Flush()
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-time.After(chunkDelay(ctx, 50*time.Millisecond)):
+		}
+	}
+}
+
 type qwenErrorResp struct {
 	Code      string `json:"code"`
 	Message   string `json:"message"`
@@ -131,34 +200,41 @@ type qwenTextGenResponse struct {
 	Usage     qwenUsage         `json:"usage"`
 }
 
-func createQwenTextGenResponse(chatRequest qwenTextGenRequest, response string) qwenTextGenResponse {
-	var output qwenTextGenOutput
+func createQwenTextGenResponse(ctx *gin.Context, chatRequest qwenTextGenRequest, response string) qwenTextGenResponse {
+	return qwenTextGenResponse{
+		Output:    qwenOutput(chatRequest, response, finishReason(ctx, nil)),
+		Usage:     qwenUsageOf(mockUsage(ctx, response)),
+		RequestId: completionMockId,
+	}
+}
+
+// qwenOutput returns the output in the requested result_format: output.choices[].message for
+// "message", output.text otherwise.
+func qwenOutput(chatRequest qwenTextGenRequest, text, finish string) qwenTextGenOutput {
 	if chatRequest.Parameters.ResultFormat == qwenResultFormatMessage {
-		output = qwenTextGenOutput{
+		return qwenTextGenOutput{
 			Choices: []qwenTextGenChoice{
 				{
-					FinishReason: stopReason,
+					FinishReason: finish,
 					Message: qwenMessage{
 						Role:    roleAssistant,
-						Content: response,
+						Content: text,
 					},
 				},
 			},
 		}
-	} else {
-		output = qwenTextGenOutput{
-			FinishReason: stopReason,
-			Text:         response,
-		}
 	}
-	return qwenTextGenResponse{
-		Output: output,
-		Usage: qwenUsage{
-			InputTokens:  9,
-			OutputTokens: 1,
-			TotalTokens:  10,
-		},
-		RequestId: completionMockId,
+	return qwenTextGenOutput{
+		FinishReason: finish,
+		Text:         text,
+	}
+}
+
+func qwenUsageOf(u usage) qwenUsage {
+	return qwenUsage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
 	}
 }
 
@@ -0,0 +1,154 @@
+package chat
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"llm-mock-server/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitHeaderWriter is implemented by providers whose real API reports the remaining quota in
+// response headers, on successful responses as well as on 429s.
+type rateLimitHeaderWriter interface {
+	setRateLimitHeaders(ctx *gin.Context, status ratelimit.Status)
+}
+
+// rateLimitErrorRenderer is implemented by providers whose real 429 carries more than their
+// generic error shape, such as the retry delay.
+type rateLimitErrorRenderer interface {
+	sendRateLimitError(ctx *gin.Context, model string, status ratelimit.Status)
+}
+
+// setRateLimitHeaders writes the quota headers of the provider, if it has any.
+func setRateLimitHeaders(ctx *gin.Context, handler requestHandler, status ratelimit.Status) {
+	if status.RequestLimit == 0 && status.TokenLimit == 0 {
+		return
+	}
+	if writer, ok := handler.(rateLimitHeaderWriter); ok {
+		writer.setRateLimitHeaders(ctx, status)
+	}
+}
+
+// sendRateLimitError rejects a request that exceeded its budget with the provider's native 429.
+func sendRateLimitError(ctx *gin.Context, handler requestHandler, model string, status ratelimit.Status) {
+	if renderer, ok := handler.(rateLimitErrorRenderer); ok {
+		renderer.sendRateLimitError(ctx, model, status)
+		return
+	}
+	sendMockError(ctx, handler, http.StatusTooManyRequests, rateLimitMessage(status))
+}
+
+func rateLimitMessage(status ratelimit.Status) string {
+	if status.Exceeded == ratelimit.Tokens {
+		return "Tokens rate limit exceeded, please try again later."
+	}
+	return "Requests rate limit exceeded, please try again later."
+}
+
+// retryAfterSeconds rounds the retry delay up to whole seconds, as the retry-after header requires.
+func retryAfterSeconds(status ratelimit.Status) string {
+	return strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds())))
+}
+
+// openAiResetDuration formats a reset delay the way OpenAI does in x-ratelimit-reset-*: "1s", "6m0s", "20ms".
+func openAiResetDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func (p *openAiProvider) setRateLimitHeaders(ctx *gin.Context, status ratelimit.Status) {
+	if status.RequestLimit > 0 {
+		ctx.Header("x-ratelimit-limit-requests", strconv.Itoa(status.RequestLimit))
+		ctx.Header("x-ratelimit-remaining-requests", strconv.Itoa(status.RequestsRemaining))
+		ctx.Header("x-ratelimit-reset-requests", openAiResetDuration(status.RequestsReset))
+	}
+	if status.TokenLimit > 0 {
+		ctx.Header("x-ratelimit-limit-tokens", strconv.Itoa(status.TokenLimit))
+		ctx.Header("x-ratelimit-remaining-tokens", strconv.Itoa(status.TokensRemaining))
+		ctx.Header("x-ratelimit-reset-tokens", openAiResetDuration(status.TokensReset))
+	}
+}
+
+func (p *openAiProvider) sendRateLimitError(ctx *gin.Context, model string, status ratelimit.Status) {
+	limit, unit := status.RequestLimit, "requests per min (RPM)"
+	if status.Exceeded == ratelimit.Tokens {
+		limit, unit = status.TokenLimit, "tokens per min (TPM)"
+	}
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"error": gin.H{
+			"message": fmt.Sprintf("Rate limit reached for %s in organization org-llm-mock on %s: Limit %d, Used %d, Requested 1. Please try again in %s. Visit https://platform.openai.com/account/rate-limits to learn more.",
+				model, unit, limit, limit, openAiResetDuration(status.RetryAfter)),
+			"type":  status.Exceeded,
+			"param": nil,
+			"code":  "rate_limit_exceeded",
+		},
+	})
+}
+
+func (p *claudeProvider) setRateLimitHeaders(ctx *gin.Context, status ratelimit.Status) {
+	now := time.Now().UTC()
+	if status.RequestLimit > 0 {
+		ctx.Header("anthropic-ratelimit-requests-limit", strconv.Itoa(status.RequestLimit))
+		ctx.Header("anthropic-ratelimit-requests-remaining", strconv.Itoa(status.RequestsRemaining))
+		ctx.Header("anthropic-ratelimit-requests-reset", now.Add(status.RequestsReset).Format(time.RFC3339))
+	}
+	if status.TokenLimit > 0 {
+		ctx.Header("anthropic-ratelimit-tokens-limit", strconv.Itoa(status.TokenLimit))
+		ctx.Header("anthropic-ratelimit-tokens-remaining", strconv.Itoa(status.TokensRemaining))
+		ctx.Header("anthropic-ratelimit-tokens-reset", now.Add(status.TokensReset).Format(time.RFC3339))
+	}
+}
+
+func (p *claudeProvider) sendRateLimitError(ctx *gin.Context, model string, status ratelimit.Status) {
+	ctx.Header("retry-after", retryAfterSeconds(status))
+	message := "Number of requests has exceeded your per-minute rate limit. Please try again later."
+	if status.Exceeded == ratelimit.Tokens {
+		message = "Number of request tokens has exceeded your per-minute rate limit. Please try again later."
+	}
+	claudeError(ctx, http.StatusTooManyRequests, "rate_limit_error", message)
+}
+
+// googleRateLimitError is the RESOURCE_EXHAUSTED error of the Gemini and Vertex APIs, whose details
+// name the exhausted quota and the retry delay.
+func googleRateLimitError(ctx *gin.Context, model string, status ratelimit.Status) {
+	metric, quotaId := "generate_content_requests", "GenerateRequestsPerMinutePerProjectPerModel"
+	if status.Exceeded == ratelimit.Tokens {
+		metric, quotaId = "generate_content_input_token_count", "GenerateContentInputTokensPerModelPerMinute"
+	}
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"error": gin.H{
+			"code":    http.StatusTooManyRequests,
+			"message": "You exceeded your current quota, please check your plan and billing details.",
+			"status":  "RESOURCE_EXHAUSTED",
+			"details": []gin.H{
+				{
+					"@type": "type.googleapis.com/google.rpc.QuotaFailure",
+					"violations": []gin.H{{
+						"quotaMetric":     "generativelanguage.googleapis.com/" + metric,
+						"quotaId":         quotaId,
+						"quotaDimensions": gin.H{"model": model},
+					}},
+				},
+				{
+					"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+					"retryDelay": retryAfterSeconds(status) + "s",
+				},
+			},
+		},
+	})
+}
+
+func (p *geminiProvider) sendRateLimitError(ctx *gin.Context, model string, status ratelimit.Status) {
+	googleRateLimitError(ctx, model, status)
+}
+
+func (p *vertexProvider) sendRateLimitError(ctx *gin.Context, model string, status ratelimit.Status) {
+	googleRateLimitError(ctx, model, status)
+}
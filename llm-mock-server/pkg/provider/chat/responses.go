@@ -0,0 +1,740 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-mock-server/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	responsesPath  = "/v1/responses"
+	objectResponse = "response"
+
+	responseStatusCompleted  = "completed"
+	responseStatusIncomplete = "incomplete"
+	responseStatusInProgress = "in_progress"
+
+	itemMessage            = "message"
+	itemFunctionCall       = "function_call"
+	itemFunctionCallOutput = "function_call_output"
+
+	contentInputText  = "input_text"
+	contentOutputText = "output_text"
+
+	roleUser = "user"
+)
+
+// responseIncompleteReasons are the incomplete_details reasons of the Responses API. The other
+// finish reasons complete the response.
+var responseIncompleteReasons = map[string]string{
+	finishLength:        "max_output_tokens",
+	finishContentFilter: "content_filter",
+}
+
+var responseRoles = []string{roleUser, roleAssistant, "system", "developer"}
+
+// DefaultResponseStoreSize is the number of responses kept when no size is configured.
+const DefaultResponseStoreSize = 1000
+
+// responseStoreKey is the gin context key under which the routes of the Responses API find their
+// store.
+const responseStoreKey = "responseStore"
+
+// ResponseStore keeps the stored responses, for previous_response_id and the retrieve and delete
+// routes. Once full, the oldest responses are dropped.
+type ResponseStore struct {
+	mutex     sync.RWMutex
+	size      int
+	sequence  int
+	ids       []string
+	responses map[string]*storedResponse
+}
+
+func NewResponseStore(size int) *ResponseStore {
+	if size <= 0 {
+		size = DefaultResponseStoreSize
+	}
+	return &ResponseStore{size: size, responses: make(map[string]*storedResponse)}
+}
+
+// storedResponse is a response with the conversation it continues.
+type storedResponse struct {
+	response *responseObject
+	// history holds the items of the previous responses of the chain.
+	history []responseItem
+	input   []responseItem
+}
+
+// conversation returns the items of the chain up to the response: the history, the input and the
+// output.
+func (r *storedResponse) conversation() []responseItem {
+	items := append([]responseItem{}, r.history...)
+	items = append(items, r.input...)
+	return append(items, r.response.Output...)
+}
+
+// next returns the sequence number of a new response, which its ids are made of.
+func (s *ResponseStore) next() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sequence++
+	return s.sequence
+}
+
+func (s *ResponseStore) add(response *storedResponse) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := response.response.Id
+	if _, ok := s.responses[id]; !ok {
+		s.ids = append(s.ids, id)
+	}
+	s.responses[id] = response
+	if overflow := len(s.ids) - s.size; overflow > 0 {
+		for _, dropped := range s.ids[:overflow] {
+			delete(s.responses, dropped)
+		}
+		s.ids = append([]string{}, s.ids[overflow:]...)
+	}
+}
+
+func (s *ResponseStore) get(id string) *storedResponse {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.responses[id]
+}
+
+func (s *ResponseStore) delete(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.responses[id]; !ok {
+		return false
+	}
+	delete(s.responses, id)
+	for i, stored := range s.ids {
+		if stored == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Reset drops all responses. The ids of the next responses keep counting so that they never reuse
+// the id of a dropped one.
+func (s *ResponseStore) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ids = nil
+	s.responses = make(map[string]*storedResponse)
+}
+
+// responseStoreOf returns the store of the routes that serve the request.
+func responseStoreOf(ctx *gin.Context) *ResponseStore {
+	if store, ok := ctx.Get(responseStoreKey); ok {
+		return store.(*ResponseStore)
+	}
+	return nil
+}
+
+// handleResponses serves the Responses API. The response continues the conversation of the
+// previous response, if any, and is stored unless store is false.
+func (p *openAiProvider) handleResponses(ctx *gin.Context) {
+	var request responsesRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := request.validate(); err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	input, err := parseResponseInput(request.Input)
+	if err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	store := responseStoreOf(ctx)
+	var history []responseItem
+	if request.PreviousResponseId != "" {
+		previous := store.get(request.PreviousResponseId)
+		if previous == nil {
+			sendOpenAiError(ctx, http.StatusBadRequest, fmt.Sprintf("Previous response with id '%s' not found.", request.PreviousResponseId))
+			return
+		}
+		history = previous.conversation()
+	}
+	conversation := append(append([]responseItem{}, history...), input...)
+	if err := validateFunctionCallOutputs(conversation); err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The previous responses of the chain are part of the prompt.
+	behavior := getMockBehavior(ctx)
+	behavior.Prompt = append(itemTexts(history), behavior.Prompt...)
+	// After a function call output the reply is to the user message of an earlier turn.
+	prompt := lastUserItemText(conversation)
+	afterToolResult := input[len(input)-1].Type == itemFunctionCallOutput
+	var choice mockChoice
+	if calls := mockToolCalls(request.mockTools(), request.toolUse(), prompt, afterToolResult); len(calls) > 0 {
+		choice = toolChoices(ctx, 1, calls)[0]
+	} else {
+		choice = responseChoices(ctx, 1, func(index int) string {
+			return choiceResponse(ctx, prompt, index)
+		})[0]
+	}
+
+	sequence := store.next()
+	response := request.newResponse(sequence)
+	completed := *response
+	completed.Output = responseOutput(choice, sequence)
+	completed.Status = responseStatusCompleted
+	if reason, ok := responseIncompleteReasons[choice.finishReason(nil)]; ok {
+		completed.Status = responseStatusIncomplete
+		completed.IncompleteDetails = &responseIncompleteDetails{Reason: reason}
+		for i := range completed.Output {
+			completed.Output[i].Status = responseStatusIncomplete
+		}
+	}
+	u := mockUsage(ctx, choiceTexts([]mockChoice{choice})...)
+	completed.Usage = &responseUsage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+	if completed.Store {
+		store.add(&storedResponse{response: &completed, history: history, input: input})
+	}
+
+	if request.Stream {
+		p.handleResponseStream(ctx, response, &completed)
+	} else {
+		ctx.JSON(http.StatusOK, &completed)
+	}
+}
+
+// handleResponseStream streams the typed events of the Responses API: the response is created,
+// then every output item is added, its text or arguments streamed as deltas and the item done, and
+// the response completes.
+func (p *openAiProvider) handleResponseStream(ctx *gin.Context, response, completed *responseObject) {
+	utils.SetEventStreamHeaders(ctx)
+	sequence := 0
+	send := func(eventType string, payload gin.H) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		default:
+		}
+		payload["type"] = eventType
+		payload["sequence_number"] = sequence
+		sequence++
+		data, _ := json.Marshal(payload)
+		// Written raw like the Claude events, since every event is named.
+		ctx.Writer.Write([]byte("event: " + eventType + "\ndata: " + string(data) + "\n\n"))
+		ctx.Writer.Flush()
+		return true
+	}
+	wait := func(fallback time.Duration) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-time.After(chunkDelay(ctx, fallback)):
+			return true
+		}
+	}
+
+	if !send("response.created", gin.H{"response": response}) || !send("response.in_progress", gin.H{"response": response}) {
+		return
+	}
+	for i, item := range completed.Output {
+		added := item
+		added.Status = responseStatusInProgress
+		if item.Type == itemFunctionCall {
+			added.Arguments = ""
+		} else {
+			added.Content = []responseContent{}
+		}
+		if !send("response.output_item.added", gin.H{"output_index": i, "item": added}) {
+			return
+		}
+		if item.Type == itemFunctionCall {
+			for _, fragment := range splitArguments(item.Arguments) {
+				if !send("response.function_call_arguments.delta", gin.H{"item_id": item.Id, "output_index": i, "delta": fragment}) || !wait(50*time.Millisecond) {
+					return
+				}
+			}
+			if !send("response.function_call_arguments.done", gin.H{"item_id": item.Id, "output_index": i, "arguments": item.Arguments}) {
+				return
+			}
+		} else {
+			for j, part := range item.Content {
+				location := gin.H{"item_id": item.Id, "output_index": i, "content_index": j}
+				if !send("response.content_part.added", withPart(location, "part", outputText(""))) {
+					return
+				}
+				for _, chunk := range streamChunks(ctx, part.Text, splitRunes) {
+					if !send("response.output_text.delta", withPart(location, "delta", chunk)) || !wait(200*time.Millisecond) {
+						return
+					}
+				}
+				if !send("response.output_text.done", withPart(location, "text", part.Text)) ||
+					!send("response.content_part.done", withPart(location, "part", part)) {
+					return
+				}
+			}
+		}
+		if !send("response.output_item.done", gin.H{"output_index": i, "item": item}) {
+			return
+		}
+	}
+	if completed.Status == responseStatusIncomplete {
+		send("response.incomplete", gin.H{"response": completed})
+	} else {
+		send("response.completed", gin.H{"response": completed})
+	}
+}
+
+// withPart returns the location of a content part with one more field.
+func withPart(location gin.H, key string, value interface{}) gin.H {
+	payload := gin.H{key: value}
+	for k, v := range location {
+		payload[k] = v
+	}
+	return payload
+}
+
+// setupResponseRoutes registers the routes that retrieve and delete the stored responses.
+func setupResponseRoutes(routes gin.IRoutes, store *ResponseStore) {
+	routes.GET(responsesPath+"/:responseId", store.handleGet)
+	routes.DELETE(responsesPath+"/:responseId", store.handleDelete)
+}
+
+// handleGet returns a stored response.
+func (s *ResponseStore) handleGet(ctx *gin.Context) {
+	stored := s.get(ctx.Param("responseId"))
+	if stored == nil {
+		sendResponseNotFound(ctx, ctx.Param("responseId"))
+		return
+	}
+	ctx.JSON(http.StatusOK, stored.response)
+}
+
+// handleDelete deletes a stored response. The responses that continue it keep their history.
+func (s *ResponseStore) handleDelete(ctx *gin.Context) {
+	id := ctx.Param("responseId")
+	if !s.delete(id) {
+		sendResponseNotFound(ctx, id)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"id": id, "object": "response.deleted", "deleted": true})
+}
+
+// sendResponseNotFound sends the 404 of an unknown response, which unlike the unknown model has no
+// error code.
+func sendResponseNotFound(ctx *gin.Context, id string) {
+	ctx.JSON(http.StatusNotFound, gin.H{
+		"error": gin.H{
+			"message": fmt.Sprintf("Response with id '%s' not found.", id),
+			"type":    "invalid_request_error",
+			"param":   nil,
+			"code":    nil,
+		},
+	})
+}
+
+// responseOutput returns the output items of the reply: a message, or the function calls.
+func responseOutput(choice mockChoice, sequence int) []responseItem {
+	if len(choice.ToolCalls) == 0 {
+		return []responseItem{{
+			Type:    itemMessage,
+			Id:      fmt.Sprintf("msg_llm-mock_%d", sequence),
+			Status:  responseStatusCompleted,
+			Role:    roleAssistant,
+			Content: []responseContent{outputText(choice.Text)},
+		}}
+	}
+	items := make([]responseItem, 0, len(choice.ToolCalls))
+	for _, call := range choice.ToolCalls {
+		items = append(items, responseItem{
+			Type:      itemFunctionCall,
+			Id:        fmt.Sprintf("fc_llm-mock_%d_%d", sequence, call.Index),
+			Status:    responseStatusCompleted,
+			CallId:    fmt.Sprintf("call_llm-mock_%d_%d", sequence, call.Index),
+			Name:      call.Name,
+			Arguments: call.argumentsJSON(),
+		})
+	}
+	return items
+}
+
+func outputText(text string) responseContent {
+	return responseContent{Type: contentOutputText, Text: text, Annotations: &[]interface{}{}}
+}
+
+// parseResponseInput returns the items of the input, which is a text or a list of items.
+func parseResponseInput(raw json.RawMessage) ([]responseItem, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("Missing required parameter: 'input'.")
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return []responseItem{{Type: itemMessage, Role: roleUser, Content: []responseContent{{Type: contentInputText, Text: text}}}}, nil
+	}
+	var inputItems []responseInputItem
+	if err := json.Unmarshal(raw, &inputItems); err != nil {
+		return nil, fmt.Errorf("Invalid 'input': expected a string or an array of input items.")
+	}
+	if len(inputItems) == 0 {
+		return nil, fmt.Errorf("Invalid 'input': empty array. Expected an array with minimum length 1.")
+	}
+	items := make([]responseItem, 0, len(inputItems))
+	for i, inputItem := range inputItems {
+		item, err := inputItem.item()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid 'input[%d]': %v", i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// validateFunctionCallOutputs checks that every function call output answers a function call of
+// the conversation.
+func validateFunctionCallOutputs(items []responseItem) error {
+	calls := make(map[string]bool)
+	for _, item := range items {
+		switch item.Type {
+		case itemFunctionCall:
+			calls[item.CallId] = true
+		case itemFunctionCallOutput:
+			if !calls[item.CallId] {
+				return fmt.Errorf("No tool call found for function call output with call_id %s.", item.CallId)
+			}
+		}
+	}
+	return nil
+}
+
+// lastUserItemText returns the text of the last user message of the items.
+func lastUserItemText(items []responseItem) string {
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == itemMessage && items[i].Role == roleUser {
+			return items[i].text()
+		}
+	}
+	return ""
+}
+
+// itemTexts returns the texts of the items, counted as prompt tokens.
+func itemTexts(items []responseItem) []string {
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		if text := item.text(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+type responsesRequest struct {
+	Model              string              `json:"model"`
+	Input              json.RawMessage     `json:"input"`
+	Instructions       string              `json:"instructions,omitempty"`
+	PreviousResponseId string              `json:"previous_response_id,omitempty"`
+	Tools              []responseTool      `json:"tools,omitempty"`
+	ToolChoice         *responseToolUse    `json:"tool_choice,omitempty"`
+	ParallelToolCalls  *bool               `json:"parallel_tool_calls,omitempty"`
+	MaxOutputTokens    *int                `json:"max_output_tokens,omitempty"`
+	Temperature        *float64            `json:"temperature,omitempty"`
+	TopP               *float64            `json:"top_p,omitempty"`
+	Store              *bool               `json:"store,omitempty"`
+	Stream             bool                `json:"stream,omitempty"`
+	Metadata           map[string]string   `json:"metadata,omitempty"`
+	Text               *responseTextFormat `json:"text,omitempty"`
+}
+
+func (r *responsesRequest) validate() error {
+	if r.Model == "" {
+		return fmt.Errorf("Missing required parameter: 'model'.")
+	}
+	for i, tool := range r.Tools {
+		if tool.Type == toolTypeFunction && tool.Name == "" {
+			return fmt.Errorf("Missing required parameter: 'tools[%d].name'.", i)
+		}
+	}
+	if r.ToolChoice != nil && r.ToolChoice.Mode != "" && r.ToolChoice.Mode != toolAuto && r.ToolChoice.Mode != toolNone && r.ToolChoice.Mode != toolRequired {
+		return fmt.Errorf("Invalid value: '%s'. Supported values are: 'none', 'auto', and 'required'.", r.ToolChoice.Mode)
+	}
+	return validateToolUse(r.mockTools(), r.toolUse())
+}
+
+// mockTools returns the function tools of the request, restricted to the allowed ones if the
+// tool_choice lists them. The built-in tools, such as web_search, are not called.
+func (r *responsesRequest) mockTools() []mockTool {
+	allowed := map[string]bool{}
+	if r.ToolChoice != nil {
+		for _, tool := range r.ToolChoice.Tools {
+			allowed[tool.Name] = true
+		}
+	}
+	var tools []mockTool
+	for _, tool := range r.Tools {
+		if tool.Type != toolTypeFunction || (len(allowed) > 0 && !allowed[tool.Name]) {
+			continue
+		}
+		tools = append(tools, mockTool{Name: tool.Name, Parameters: tool.Parameters})
+	}
+	return tools
+}
+
+// toolUse returns how the tool_choice and parallel_tool_calls of the request let the model use
+// its tools.
+func (r *responsesRequest) toolUse() toolUse {
+	use := toolUse{Mode: toolAuto, Single: r.ParallelToolCalls != nil && !*r.ParallelToolCalls}
+	if r.ToolChoice != nil {
+		if r.ToolChoice.Mode != "" {
+			use.Mode = r.ToolChoice.Mode
+		}
+		use.Name = r.ToolChoice.Name
+	}
+	return use
+}
+
+// newResponse returns the response to the request, in progress and without output yet.
+func (r *responsesRequest) newResponse(sequence int) *responseObject {
+	response := &responseObject{
+		Id:                fmt.Sprintf("resp_llm-mock_%d", sequence),
+		Object:            objectResponse,
+		CreatedAt:         completionMockCreated,
+		Status:            responseStatusInProgress,
+		MaxOutputTokens:   r.MaxOutputTokens,
+		Model:             r.Model,
+		Output:            []responseItem{},
+		ParallelToolCalls: r.ParallelToolCalls == nil || *r.ParallelToolCalls,
+		Store:             r.Store == nil || *r.Store,
+		Temperature:       1,
+		TopP:              1,
+		ToolChoice:        r.ToolChoice,
+		Tools:             r.Tools,
+		Metadata:          r.Metadata,
+		Text:              r.Text,
+	}
+	if r.Instructions != "" {
+		response.Instructions = &r.Instructions
+	}
+	if r.PreviousResponseId != "" {
+		response.PreviousResponseId = &r.PreviousResponseId
+	}
+	if r.Temperature != nil {
+		response.Temperature = *r.Temperature
+	}
+	if r.TopP != nil {
+		response.TopP = *r.TopP
+	}
+	if response.ToolChoice == nil {
+		response.ToolChoice = &responseToolUse{Mode: toolAuto}
+	}
+	if response.Tools == nil {
+		response.Tools = []responseTool{}
+	}
+	if response.Metadata == nil {
+		response.Metadata = map[string]string{}
+	}
+	if response.Text == nil {
+		response.Text = &responseTextFormat{Format: map[string]interface{}{"type": "text"}}
+	}
+	return response
+}
+
+type responseTool struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// responseToolUse is the tool_choice of the Responses API: "none", "auto" or "required", a
+// function ({"type": "function", "name": ...}) or allowed tools ({"type": "allowed_tools", "mode":
+// ..., "tools": [...]}).
+type responseToolUse struct {
+	Mode  string
+	Name  string
+	Tools []responseTool
+	raw   json.RawMessage
+}
+
+func (c *responseToolUse) UnmarshalJSON(data []byte) error {
+	c.raw = append(json.RawMessage{}, data...)
+	if json.Unmarshal(data, &c.Mode) == nil {
+		return nil
+	}
+	var object struct {
+		Type  string         `json:"type"`
+		Name  string         `json:"name"`
+		Mode  string         `json:"mode"`
+		Tools []responseTool `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	switch object.Type {
+	case toolTypeFunction:
+		c.Name = object.Name
+	case "allowed_tools":
+		c.Mode, c.Tools = object.Mode, object.Tools
+	}
+	return nil
+}
+
+// MarshalJSON returns the tool_choice as it was requested.
+func (c *responseToolUse) MarshalJSON() ([]byte, error) {
+	if c.raw != nil {
+		return c.raw, nil
+	}
+	return json.Marshal(c.Mode)
+}
+
+type responseTextFormat struct {
+	Format map[string]interface{} `json:"format,omitempty"`
+}
+
+// responseInputItem is an item of the input: a message, a function call of a previous turn or the
+// output of that call.
+type responseInputItem struct {
+	Type      string          `json:"type"`
+	Id        string          `json:"id,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	CallId    string          `json:"call_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+}
+
+func (i responseInputItem) item() (responseItem, error) {
+	switch i.Type {
+	case "", itemMessage:
+		if !containsString(responseRoles, i.Role) {
+			return responseItem{}, fmt.Errorf("invalid role '%s'. Supported values are: 'user', 'assistant', 'system', and 'developer'.", i.Role)
+		}
+		item := responseItem{Type: itemMessage, Id: i.Id, Role: i.Role}
+		var text string
+		if json.Unmarshal(i.Content, &text) == nil {
+			item.Content = []responseContent{{Type: contentInputText, Text: text}}
+			if i.Role == roleAssistant {
+				item.Content = []responseContent{outputText(text)}
+			}
+			return item, nil
+		}
+		if err := json.Unmarshal(i.Content, &item.Content); err != nil || len(item.Content) == 0 {
+			return responseItem{}, fmt.Errorf("content must be a string or an array of content parts")
+		}
+		return item, nil
+	case itemFunctionCall:
+		if i.CallId == "" || i.Name == "" {
+			return responseItem{}, fmt.Errorf("a function call must have a call_id and a name")
+		}
+		return responseItem{Type: itemFunctionCall, Id: i.Id, CallId: i.CallId, Name: i.Name, Arguments: i.Arguments, Status: responseStatusCompleted}, nil
+	case itemFunctionCallOutput:
+		if i.CallId == "" {
+			return responseItem{}, fmt.Errorf("a function call output must have a call_id")
+		}
+		var output string
+		if json.Unmarshal(i.Output, &output) != nil {
+			// An output made of content parts is kept as JSON.
+			output = string(i.Output)
+		}
+		return responseItem{Type: itemFunctionCallOutput, Id: i.Id, CallId: i.CallId, Output: output}, nil
+	}
+	return responseItem{}, fmt.Errorf("invalid type '%s'. Supported values are: 'message', 'function_call', and 'function_call_output'.", i.Type)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// responseItem is an item of the input or the output of a response.
+type responseItem struct {
+	Type      string            `json:"type"`
+	Id        string            `json:"id,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Role      string            `json:"role,omitempty"`
+	Content   []responseContent `json:"content,omitempty"`
+	CallId    string            `json:"call_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Arguments string            `json:"arguments,omitempty"`
+	Output    string            `json:"output,omitempty"`
+}
+
+// text returns the text of a message, the call of a function call or the output of a function call.
+func (i responseItem) text() string {
+	switch i.Type {
+	case itemFunctionCall:
+		return i.Name + i.Arguments
+	case itemFunctionCallOutput:
+		return i.Output
+	}
+	texts := make([]string, 0, len(i.Content))
+	for _, part := range i.Content {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+type responseContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	// Annotations are only returned with the output text, always empty.
+	Annotations *[]interface{} `json:"annotations,omitempty"`
+}
+
+type responseObject struct {
+	Id        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	Status    string `json:"status"`
+	// Error is always null: the mock fails with HTTP errors instead.
+	Error              interface{}                `json:"error"`
+	IncompleteDetails  *responseIncompleteDetails `json:"incomplete_details"`
+	Instructions       *string                    `json:"instructions"`
+	MaxOutputTokens    *int                       `json:"max_output_tokens"`
+	Model              string                     `json:"model"`
+	Output             []responseItem             `json:"output"`
+	ParallelToolCalls  bool                       `json:"parallel_tool_calls"`
+	PreviousResponseId *string                    `json:"previous_response_id"`
+	Store              bool                       `json:"store"`
+	Temperature        float64                    `json:"temperature"`
+	TopP               float64                    `json:"top_p"`
+	Text               *responseTextFormat        `json:"text"`
+	ToolChoice         *responseToolUse           `json:"tool_choice"`
+	Tools              []responseTool             `json:"tools"`
+	Usage              *responseUsage             `json:"usage"`
+	Metadata           map[string]string          `json:"metadata"`
+}
+
+type responseIncompleteDetails struct {
+	Reason string `json:"reason"`
+}
+
+type responseUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokens        int `json:"output_tokens"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+	TotalTokens int `json:"total_tokens"`
+}
@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseResponseInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "text", input: `"hello"`, expected: []string{"message:user:hello"}},
+		{name: "messages", input: `[{"role":"developer","content":"be brief"},{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}]`,
+			expected: []string{"message:developer:be brief", "message:user:hi"}},
+		{name: "function call", input: `[{"type":"function_call","call_id":"call_1","name":"get_time","arguments":"{}"},{"type":"function_call_output","call_id":"call_1","output":"noon"}]`,
+			expected: []string{"function_call::get_time{}", "function_call_output::noon"}},
+		{name: "missing", input: ``, wantErr: true},
+		{name: "empty", input: `[]`, wantErr: true},
+		{name: "unknown role", input: `[{"role":"tool","content":"x"}]`, wantErr: true},
+		{name: "unknown type", input: `[{"type":"reasoning"}]`, wantErr: true},
+		{name: "call without name", input: `[{"type":"function_call","call_id":"call_1"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseResponseInput(json.RawMessage(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, item.Type+":"+item.Role+":"+item.text())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected items %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateFunctionCallOutputs(t *testing.T) {
+	call := responseItem{Type: itemFunctionCall, CallId: "call_1", Name: "get_time"}
+	output := responseItem{Type: itemFunctionCallOutput, CallId: "call_1"}
+	if err := validateFunctionCallOutputs([]responseItem{call, output}); err != nil {
+		t.Errorf("Expected the output of a call to be valid, got %v", err)
+	}
+	if err := validateFunctionCallOutputs([]responseItem{output, call}); err == nil {
+		t.Error("Expected an output before its call to be rejected")
+	}
+}
+
+func TestResponseStore(t *testing.T) {
+	store := NewResponseStore(2)
+	first := &storedResponse{
+		response: &responseObject{Id: "resp_1", Output: []responseItem{{Type: itemMessage, Role: roleAssistant}}},
+		input:    []responseItem{{Type: itemMessage, Role: roleUser}},
+	}
+	store.add(first)
+	second := &storedResponse{
+		response: &responseObject{Id: "resp_2", Output: []responseItem{{Type: itemFunctionCall}}},
+		history:  store.get("resp_1").conversation(),
+		input:    []responseItem{{Type: itemMessage, Role: roleUser}},
+	}
+	store.add(second)
+
+	if got := len(second.conversation()); got != 4 {
+		t.Errorf("Expected the chain to hold 4 items, got %d", got)
+	}
+	if !store.delete("resp_1") || store.delete("resp_1") {
+		t.Error("Expected a response to be deleted once")
+	}
+	if store.get("resp_1") != nil || store.get("resp_2") == nil {
+		t.Error("Expected only the deleted response to be gone")
+	}
+
+	store.add(&storedResponse{response: &responseObject{Id: "resp_3"}})
+	store.add(&storedResponse{response: &responseObject{Id: "resp_4"}})
+	if store.get("resp_2") != nil || store.get("resp_3") == nil || store.get("resp_4") == nil {
+		t.Error("Expected the oldest response to be dropped once the store is full")
+	}
+	sequence := store.next()
+	store.Reset()
+	if store.get("resp_4") != nil || store.next() != sequence+1 {
+		t.Error("Expected the reset to drop the responses and keep the sequence")
+	}
+}
@@ -0,0 +1,155 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"llm-mock-server/pkg/schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tool modes, in the terms of the OpenAI tool_choice. The other providers map theirs onto them:
+// Anthropic "any" and Gemini "ANY" are toolRequired.
+const (
+	toolAuto     = "auto"
+	toolNone     = "none"
+	toolRequired = "required"
+)
+
+// mockTool is a tool declared by the request, in a provider-neutral form.
+type mockTool struct {
+	Name string
+	// Parameters is the JSON schema of the arguments.
+	Parameters map[string]interface{}
+}
+
+// toolUse is how the request lets the model use its tools.
+type toolUse struct {
+	// Mode is toolAuto, toolNone or toolRequired. Empty is toolAuto.
+	Mode string
+	// Name forces a call of the named tool.
+	Name string
+	// Single disables parallel tool calls.
+	Single bool
+}
+
+// mockToolCall is a tool call of the reply.
+type mockToolCall struct {
+	Index int
+	Name  string
+	// Arguments are generated from the parameters of the tool.
+	Arguments interface{}
+}
+
+// argumentsJSON returns the arguments encoded as JSON, the way OpenAI and Anthropic stream them.
+func (c mockToolCall) argumentsJSON() string {
+	data, _ := json.Marshal(c.Arguments)
+	return string(data)
+}
+
+// id returns the id of the call with the provider's prefix, e.g. "call_llm-mock_0".
+func (c mockToolCall) id(prefix string) string {
+	return fmt.Sprintf("%s_llm-mock_%d", prefix, c.Index)
+}
+
+// validateToolUse checks that a forced tool is among the tools of the request, which OpenAI rejects
+// with a 400 rather than answering with text.
+func validateToolUse(tools []mockTool, use toolUse) error {
+	if use.Name == "" {
+		return nil
+	}
+	for _, tool := range tools {
+		if tool.Name == use.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("Tool choice '%s' not found in 'tools' parameter.", use.Name)
+}
+
+// mockToolCalls returns the tool calls of the reply, or nil if the model answers with text.
+//
+// A forced tool is always called. Otherwise, in auto mode the model calls tools unless the last
+// message is a tool result, so that an agent loop gets a final answer on its second turn; in
+// required mode it always calls tools. It then calls the tools named in the prompt, or the first
+// tool if the prompt names none, and a single tool when parallel calls are disabled.
+func mockToolCalls(tools []mockTool, use toolUse, prompt string, afterToolResult bool) []mockToolCall {
+	if len(tools) == 0 || use.Mode == toolNone {
+		return nil
+	}
+	var called []mockTool
+	if use.Name != "" {
+		for _, tool := range tools {
+			if tool.Name == use.Name {
+				called = append(called, tool)
+				break
+			}
+		}
+	} else {
+		if afterToolResult && use.Mode != toolRequired {
+			return nil
+		}
+		for _, tool := range tools {
+			if tool.Name != "" && strings.Contains(prompt, tool.Name) {
+				called = append(called, tool)
+			}
+		}
+		if len(called) == 0 {
+			called = tools[:1]
+		}
+	}
+	if use.Single && len(called) > 1 {
+		called = called[:1]
+	}
+	calls := make([]mockToolCall, 0, len(called))
+	for i, tool := range called {
+		arguments := schema.Example(tool.Parameters)
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		calls = append(calls, mockToolCall{Index: i, Name: tool.Name, Arguments: arguments})
+	}
+	return calls
+}
+
+// toolChoices returns the n choices of a reply calling tools. The finish reason reports the tool
+// calls unless another one was requested.
+func toolChoices(ctx *gin.Context, n int, calls []mockToolCall) []mockChoice {
+	behavior := getMockBehavior(ctx)
+	if behavior.Finish == "" {
+		behavior.Finish = finishToolCalls
+	}
+	if n < 1 {
+		n = 1
+	}
+	choices := make([]mockChoice, 0, n)
+	for i := 0; i < n; i++ {
+		choices = append(choices, mockChoice{Index: i, Finish: behavior.Finish, ToolCalls: calls})
+	}
+	return choices
+}
+
+// toolCallTexts returns the texts the tool calls are billed for: the name and the arguments.
+func toolCallTexts(calls []mockToolCall) []string {
+	texts := make([]string, 0, len(calls))
+	for _, call := range calls {
+		texts = append(texts, call.Name+call.argumentsJSON())
+	}
+	return texts
+}
+
+// splitArguments streams the JSON arguments of a tool call in small fragments, like the real APIs.
+func splitArguments(arguments string) []string {
+	const size = 8
+	var fragments []string
+	runes := []rune(arguments)
+	for i := 0; i < len(runes); i += size {
+		end := i + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		fragments = append(fragments, string(runes[i:end]))
+	}
+	return fragments
+}
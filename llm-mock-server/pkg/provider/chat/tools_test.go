@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMockToolCalls(t *testing.T) {
+	tools := []mockTool{
+		{Name: "get_weather", Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		}},
+		{Name: "get_time"},
+	}
+	tests := []struct {
+		name            string
+		use             toolUse
+		prompt          string
+		afterToolResult bool
+		expected        []string
+	}{
+		{name: "first tool", prompt: "hello", expected: []string{"get_weather"}},
+		{name: "named in prompt", prompt: "use get_time", expected: []string{"get_time"}},
+		{name: "parallel", prompt: "get_time and get_weather", expected: []string{"get_weather", "get_time"}},
+		{name: "single", use: toolUse{Single: true}, prompt: "get_time and get_weather", expected: []string{"get_weather"}},
+		{name: "none", use: toolUse{Mode: toolNone}, prompt: "get_time"},
+		{name: "final answer", prompt: "sunny", afterToolResult: true},
+		{name: "required", use: toolUse{Mode: toolRequired}, afterToolResult: true, expected: []string{"get_weather"}},
+		{name: "forced", use: toolUse{Name: "get_time"}, prompt: "get_weather", afterToolResult: true, expected: []string{"get_time"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, call := range mockToolCalls(tools, tt.use, tt.prompt, tt.afterToolResult) {
+				names = append(names, call.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected calls of %v, got %v", tt.expected, names)
+			}
+		})
+	}
+
+	calls := mockToolCalls(tools, toolUse{}, "get_weather get_time", false)
+	if got := calls[0].argumentsJSON(); got != `{"city":"mock city"}` {
+		t.Errorf("Expected the arguments to follow the parameters, got %s", got)
+	}
+	if got := calls[1].argumentsJSON(); got != `{}` {
+		t.Errorf("Expected a tool without parameters to get empty arguments, got %s", got)
+	}
+}
+
+func TestValidateToolUse(t *testing.T) {
+	tools := []mockTool{{Name: "get_weather"}}
+	if err := validateToolUse(tools, toolUse{Name: "get_weather"}); err != nil {
+		t.Errorf("Expected a declared tool to be accepted, got %v", err)
+	}
+	if err := validateToolUse(tools, toolUse{Mode: toolRequired}); err != nil {
+		t.Errorf("Expected no forced tool to be accepted, got %v", err)
+	}
+	if err := validateToolUse(tools, toolUse{Name: "get_time"}); err == nil {
+		t.Error("Expected an undeclared forced tool to be rejected")
+	}
+}
+
+func TestOpenAiToolCallsShape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	SetupRoutes(server, "", nil, nil)
+	tools := `"tools": [{"type": "function", "function": {"name": "get_time"}}]`
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "non-stream",
+			body:     `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}], ` + tools + `}`,
+			status:   http.StatusOK,
+			expected: `{"role":"assistant","content":null,"tool_calls":[{"id":"call_llm-mock_0","type":"function","function":{"name":"get_time","arguments":"{}"}}]}`,
+		},
+		{
+			name:   "unknown forced tool",
+			body:   `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}], "tool_choice": {"type": "function", "function": {"name": "get_weather"}}, ` + tools + `}`,
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			if recorder.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, recorder.Code, recorder.Body.String())
+			}
+			if tt.expected == "" {
+				return
+			}
+			var response struct {
+				Choices []struct {
+					Message json.RawMessage `json:"message"`
+				} `json:"choices"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(response.Choices[0].Message); got != tt.expected {
+				t.Errorf("Expected message %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -3,15 +3,52 @@ package chat
 import (
 	"net/http"
 
+	"llm-mock-server/pkg/generator"
 	"llm-mock-server/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func prompt2Response(prompt string) string {
+// prompt2Response returns the reply to the prompt: the configured reply, the output of the
+// configured generator, or the prompt itself, truncated to the max tokens of the request.
+func prompt2Response(ctx *gin.Context, prompt string) string {
+	return truncateResponse(ctx, choiceResponse(ctx, prompt, 0))
+}
+
+// choiceResponse returns the reply of a choice to the prompt, before it is cut at the stop
+// sequences and the max tokens of the request.
+func choiceResponse(ctx *gin.Context, prompt string, index int) string {
+	behavior := getMockBehavior(ctx)
+	if behavior.Reply != "" {
+		return behavior.Reply
+	}
+	prompt = stripMockDirective(prompt)
+	if behavior.Generator != nil {
+		return generator.GenerateChoice(behavior.Generator, prompt, behavior.Model, index)
+	}
 	return prompt
 }
 
+// configuredResponse returns the configured reply or the generated one, for the providers whose
+// default reply is not the echoed prompt. It reports false if neither is configured.
+func configuredResponse(ctx *gin.Context) (string, bool) {
+	response, ok := configuredChoice(ctx, 0)
+	if !ok {
+		return "", false
+	}
+	return truncateResponse(ctx, response), true
+}
+
+// configuredChoice is configuredResponse for one of several choices, before truncation.
+func configuredChoice(ctx *gin.Context, index int) (string, bool) {
+	behavior := getMockBehavior(ctx)
+	if behavior.Reply == "" && behavior.Generator == nil {
+		return "", false
+	}
+	requestCtx, _ := getRequestContext(ctx)
+	return choiceResponse(ctx, requestCtx.Prompt, index), true
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
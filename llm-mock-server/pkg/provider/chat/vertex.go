@@ -1,12 +1,10 @@
 package chat
 
 import (
-	"encoding/json"
 	"fmt"
 	"llm-mock-server/pkg/log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -58,24 +56,31 @@ func (p *vertexProvider) HandleChatCompletions(ctx *gin.Context) {
 	}
 	log.Infof("vertex request model: %s, action: %s", model, action)
 
-	var vertexRequest vertexGenerateContentRequest
+	// Vertex shares the generateContent format of Gemini.
+	var vertexRequest geminiGenerateContentRequest
 	if err := ctx.ShouldBindJSON(&vertexRequest); err != nil {
 		p.sendErrorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err.Error()))
 		return
 	}
 
-	if err := p.validateRequest(&vertexRequest); err != nil {
+	if err := vertexRequest.validate(); err != nil {
 		p.sendErrorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("Validation error: %v", err.Error()))
 		return
 	}
 
 	isStreaming := action == vertexActionStreamGenerate
-	content := p.generateResponse(&vertexRequest)
+	// The intro makes the response identifiable as served by the Vertex simulation.
+	choices := generateContentChoices(ctx, &vertexRequest, "This is a mock response from Vertex provider. ")
 
 	if isStreaming {
-		p.handleStreamResponse(ctx, content)
+		streamGenerateContent(ctx, choices)
 	} else {
-		p.handleNonStreamResponse(ctx, content)
+		// The schema matches what ai-proxy's vertex provider parses (responseId /
+		// candidates[].content.parts[].text / finishReason / usageMetadata), so it
+		// round-trips into an OpenAI response.
+		response := generateContentResponse(ctx, choices)
+		response.ResponseId = completionMockId
+		ctx.JSON(http.StatusOK, response)
 	}
 }
 
@@ -96,204 +101,18 @@ func parseVertexModelAndAction(path string) (string, string, bool) {
 	return parts[0], parts[1], true
 }
 
-func (p *vertexProvider) validateRequest(req *vertexGenerateContentRequest) error {
-	if len(req.Contents) == 0 {
-		return fmt.Errorf("contents are required")
-	}
-
-	for i, content := range req.Contents {
-		if len(content.Parts) == 0 {
-			return fmt.Errorf("content %d: parts are required", i)
-		}
-		for j, part := range content.Parts {
-			if part.Text == "" {
-				return fmt.Errorf("content %d, part %d: text is required", i, j)
-			}
-		}
-	}
-
-	return nil
-}
-
-func (p *vertexProvider) generateResponse(req *vertexGenerateContentRequest) string {
-	// Generate the mock reply content, mirroring the Gemini provider so the
-	// response is identifiable as having been served by the Vertex simulation.
-	content := "This is a mock response from Vertex provider. "
-	if len(req.Contents) > 0 {
-		lastContent := req.Contents[len(req.Contents)-1]
-		if len(lastContent.Parts) > 0 {
-			runes := []rune(lastContent.Parts[0].Text)
-			if len(runes) > 50 {
-				content += "You said: " + string(runes[:50]) + "..."
-			} else {
-				content += "You said: " + string(runes)
-			}
-		}
-	}
-	return content
-}
-
-func (p *vertexProvider) handleStreamResponse(ctx *gin.Context, response string) {
-	// Set streaming response headers
-	ctx.Header("Content-Type", "text/event-stream")
-	ctx.Header("Cache-Control", "no-cache")
-	ctx.Header("Connection", "keep-alive")
-	ctx.Header("Access-Control-Allow-Origin", "*")
-
-	// Split the reply into words and stream them
-	words := strings.Fields(response)
-	totalWords := len(words)
-
-	for i, word := range words {
-		select {
-		case <-ctx.Request.Context().Done():
-			return
-		default:
-		}
-
-		chunk := vertexGenerateContentResponse{
-			Candidates: []vertexCandidate{
-				{
-					Content: vertexContent{
-						Parts: []vertexPart{
-							{Text: word + " "},
-						},
-						Role: "model",
-					},
-					Index: 0,
-				},
-			},
-			UsageMetadata: &vertexUsageMetadata{
-				PromptTokenCount:     completionMockUsage.PromptTokens,
-				CandidatesTokenCount: completionMockUsage.CompletionTokens,
-				TotalTokenCount:      completionMockUsage.TotalTokens,
-			},
-		}
-
-		// The final chunk carries the finish reason
-		if i == totalWords-1 {
-			chunk.Candidates[0].FinishReason = "STOP"
-		}
-
-		// Send the data chunk and flush immediately so each event arrives on its own
-		data, err := json.Marshal(chunk)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal response"})
-			return
-		}
-
-		ctx.Render(-1, streamEvent{Data: "data: " + string(data)})
-		ctx.Writer.Flush()
-
-		select {
-		case <-ctx.Request.Context().Done():
-			return
-		case <-time.After(50 * time.Millisecond):
-		}
-	}
-}
-
-func (p *vertexProvider) handleNonStreamResponse(ctx *gin.Context, response string) {
-	// Build the non-streaming response. The schema matches what ai-proxy's vertex
-	// provider parses (responseId / candidates[].content.parts[].text /
-	// finishReason / usageMetadata), so it round-trips into an OpenAI response.
-	vertexResponse := vertexGenerateContentResponse{
-		ResponseId: completionMockId,
-		Candidates: []vertexCandidate{
-			{
-				Content: vertexContent{
-					Parts: []vertexPart{
-						{Text: response},
-					},
-					Role: "model",
-				},
-				FinishReason: "STOP",
-				Index:        0,
-			},
-		},
-		UsageMetadata: &vertexUsageMetadata{
-			PromptTokenCount:     completionMockUsage.PromptTokens,
-			CandidatesTokenCount: completionMockUsage.CompletionTokens,
-			TotalTokenCount:      completionMockUsage.TotalTokens,
-		},
-	}
-
-	ctx.JSON(http.StatusOK, vertexResponse)
+func (p *vertexProvider) sendMockError(ctx *gin.Context, statusCode int, message string) {
+	p.sendErrorResponse(ctx, statusCode, message)
 }
 
+// sendErrorResponse sends the google.rpc error of Vertex, which pairs the same status names with
+// the HTTP status codes as Gemini.
 func (p *vertexProvider) sendErrorResponse(ctx *gin.Context, statusCode int, message string) {
 	ctx.JSON(statusCode, gin.H{
 		"error": gin.H{
 			"code":    statusCode,
 			"message": message,
-			"status":  vertexErrorStatus(statusCode),
+			"status":  geminiErrorStatus(statusCode),
 		},
 	})
 }
-
-// vertexErrorStatus returns the google.rpc.Code name that the real Vertex API
-// pairs with the given HTTP status code.
-func vertexErrorStatus(statusCode int) string {
-	switch statusCode {
-	case http.StatusBadRequest:
-		return "INVALID_ARGUMENT"
-	case http.StatusUnauthorized:
-		return "UNAUTHENTICATED"
-	case http.StatusForbidden:
-		return "PERMISSION_DENIED"
-	case http.StatusNotFound:
-		return "NOT_FOUND"
-	case http.StatusTooManyRequests:
-		return "RESOURCE_EXHAUSTED"
-	default:
-		return "INTERNAL"
-	}
-}
-
-// Data structures of Vertex generateContent requests and responses.
-// The request schema matches Google's generateContent API (contents/parts),
-// which is the body ai-proxy produces after transforming an OpenAI request.
-type vertexGenerateContentRequest struct {
-	Contents         []vertexContent         `json:"contents"`
-	SafetySettings   []vertexSafetySetting   `json:"safetySettings,omitempty"`
-	GenerationConfig *vertexGenerationConfig `json:"generationConfig,omitempty"`
-}
-
-type vertexContent struct {
-	Parts []vertexPart `json:"parts"`
-	Role  string       `json:"role,omitempty"`
-}
-
-type vertexPart struct {
-	Text string `json:"text"`
-}
-
-type vertexSafetySetting struct {
-	Category  string `json:"category"`
-	Threshold string `json:"threshold"`
-}
-
-type vertexGenerationConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	TopP            float64 `json:"topP,omitempty"`
-	TopK            int     `json:"topK,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-}
-
-type vertexGenerateContentResponse struct {
-	ResponseId    string               `json:"responseId,omitempty"`
-	Candidates    []vertexCandidate    `json:"candidates"`
-	UsageMetadata *vertexUsageMetadata `json:"usageMetadata,omitempty"`
-}
-
-type vertexCandidate struct {
-	Content      vertexContent `json:"content"`
-	FinishReason string        `json:"finishReason,omitempty"`
-	Index        int           `json:"index"`
-}
-
-type vertexUsageMetadata struct {
-	PromptTokenCount     int `json:"promptTokenCount"`
-	CandidatesTokenCount int `json:"candidatesTokenCount"`
-	TotalTokenCount      int `json:"totalTokenCount"`
-}
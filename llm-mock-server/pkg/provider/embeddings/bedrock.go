@@ -0,0 +1,157 @@
+package embeddings
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	bedrockHostFragment   = "bedrock"
+	bedrockDomainFragment = "amazonaws.com"
+	bedrockModelPath      = "/model/"
+	bedrockInvokePath     = "/invoke"
+
+	titanModelPrefix  = "amazon.titan-embed-text-"
+	titanV1Model      = "amazon.titan-embed-text-v1"
+	cohereModelPrefix = "cohere.embed-"
+)
+
+// titanV2Dimensions are the dimensions Titan Text Embeddings V2 supports, the first one being the
+// default. V1 always returns titanV1Dimensions.
+var titanV2Dimensions = []int{1024, 512, 256}
+
+const titanV1Dimensions = 1536
+
+type bedrockProvider struct{}
+
+func (p *bedrockProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	host := ctx.Request.Host
+	return strings.Contains(host, bedrockHostFragment) && strings.Contains(host, bedrockDomainFragment) &&
+		strings.HasPrefix(ctx.Request.URL.Path, bedrockModelPath) &&
+		strings.HasSuffix(ctx.Request.URL.Path, bedrockInvokePath)
+}
+
+func (p *bedrockProvider) HandleEmbeddings(ctx *gin.Context) {
+	// Auth is not enforced, like the Bedrock chat mock.
+	model := strings.TrimSuffix(strings.TrimPrefix(ctx.Request.URL.Path, bedrockModelPath), bedrockInvokePath)
+	switch {
+	case strings.HasPrefix(model, titanModelPrefix):
+		p.handleTitan(ctx, model)
+	case strings.HasPrefix(model, cohereModelPrefix):
+		var request cohereEmbedRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			sendBedrockError(ctx, http.StatusBadRequest, fmt.Sprintf("Malformed input request: %v", err))
+			return
+		}
+		request.Model = model
+		response, err := cohereEmbed(&request, true)
+		if err != nil {
+			sendBedrockError(ctx, http.StatusBadRequest, fmt.Sprintf("Malformed input request: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, response)
+	default:
+		sendBedrockError(ctx, http.StatusBadRequest, "The provided model identifier is invalid.")
+	}
+}
+
+func (p *bedrockProvider) handleTitan(ctx *gin.Context, model string) {
+	var request titanEmbedRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendBedrockError(ctx, http.StatusBadRequest, fmt.Sprintf("Malformed input request: %v", err))
+		return
+	}
+	if request.InputText == "" {
+		sendBedrockError(ctx, http.StatusBadRequest, "Malformed input request: inputText must not be empty")
+		return
+	}
+	dimensions := titanV2Dimensions[0]
+	embeddingTypes := request.EmbeddingTypes
+	if model == titanV1Model {
+		// V1 only takes the input text.
+		if request.Dimensions != nil || request.Normalize != nil || len(request.EmbeddingTypes) > 0 {
+			sendBedrockError(ctx, http.StatusBadRequest, "Malformed input request: extraneous key is not permitted")
+			return
+		}
+		dimensions = titanV1Dimensions
+	} else {
+		if request.Dimensions != nil {
+			if !containsInt(titanV2Dimensions, *request.Dimensions) {
+				sendBedrockError(ctx, http.StatusBadRequest, fmt.Sprintf("Malformed input request: dimensions must be one of %v", titanV2Dimensions))
+				return
+			}
+			dimensions = *request.Dimensions
+		}
+		for _, embeddingType := range embeddingTypes {
+			if embeddingType != typeFloat && embeddingType != typeBinary {
+				sendBedrockError(ctx, http.StatusBadRequest, "Malformed input request: embeddingTypes must be among float, binary")
+				return
+			}
+		}
+		if len(embeddingTypes) == 0 {
+			embeddingTypes = []string{typeFloat}
+		}
+	}
+
+	values := currentEmbedder.embed(request.InputText, dimensions)
+	response := titanEmbedResponse{
+		Embedding:           values,
+		InputTextTokenCount: tokenizer.ForModel(model).Count(request.InputText),
+	}
+	if len(embeddingTypes) > 0 {
+		response.EmbeddingsByType = make(map[string]interface{}, len(embeddingTypes))
+		for _, embeddingType := range embeddingTypes {
+			if embeddingType == typeBinary {
+				// Titan returns a 0 or 1 for every dimension, unpacked.
+				bits := make([]int, len(values))
+				for i, value := range values {
+					if value > 0 {
+						bits[i] = 1
+					}
+				}
+				response.EmbeddingsByType[embeddingType] = bits
+			} else {
+				response.EmbeddingsByType[embeddingType] = values
+			}
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// sendBedrockError sends the error of the Bedrock API, which names the exception in the
+// x-amzn-ErrorType header.
+func sendBedrockError(ctx *gin.Context, statusCode int, message string) {
+	exception := "InternalServerException"
+	if statusCode == http.StatusBadRequest {
+		exception = "ValidationException"
+	}
+	ctx.Header("x-amzn-ErrorType", exception)
+	ctx.JSON(statusCode, gin.H{"message": message})
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type titanEmbedRequest struct {
+	InputText      string   `json:"inputText"`
+	Dimensions     *int     `json:"dimensions,omitempty"`
+	Normalize      *bool    `json:"normalize,omitempty"`
+	EmbeddingTypes []string `json:"embeddingTypes,omitempty"`
+}
+
+type titanEmbedResponse struct {
+	Embedding           []float64              `json:"embedding"`
+	InputTextTokenCount int                    `json:"inputTextTokenCount"`
+	EmbeddingsByType    map[string]interface{} `json:"embeddingsByType,omitempty"`
+}
@@ -0,0 +1,162 @@
+package embeddings
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cohereDomain    = "api.cohere.com"
+	cohereEmbedPath = "/v1/embed"
+
+	cohereDefaultModel = "embed-english-v2.0"
+	// cohereMaxTexts is the maximum number of texts of a request.
+	cohereMaxTexts = 96
+
+	cohereResponseFloats = "embeddings_floats"
+	cohereResponseByType = "embeddings_by_type"
+)
+
+// cohereDimensions are the dimensions of the Cohere embedding models, on the Cohere API and on
+// Bedrock. Other models get cohereDefaultDimensions.
+var cohereDimensions = map[string]int{
+	"embed-english-v2.0":            4096,
+	"embed-english-light-v2.0":      1024,
+	"embed-multilingual-v2.0":       768,
+	"embed-english-v3.0":            1024,
+	"embed-english-light-v3.0":      384,
+	"embed-multilingual-v3.0":       1024,
+	"embed-multilingual-light-v3.0": 384,
+	"embed-v4.0":                    1536,
+	"cohere.embed-english-v3":       1024,
+	"cohere.embed-multilingual-v3":  1024,
+	"cohere.embed-v4:0":             1536,
+}
+
+const cohereDefaultDimensions = 1024
+
+var (
+	cohereInputTypes     = []string{"search_document", "search_query", "classification", "clustering", "image"}
+	cohereEmbeddingTypes = []string{typeFloat, typeInt8, typeUint8, typeBinary, typeUbinary}
+)
+
+type cohereProvider struct{}
+
+func (p *cohereProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return ctx.Request.Host == cohereDomain && ctx.Request.URL.Path == cohereEmbedPath
+}
+
+func (p *cohereProvider) HandleEmbeddings(ctx *gin.Context) {
+	// The real Cohere API requires "Authorization: Bearer <api key>"; ai-proxy always injects it.
+	if ctx.GetHeader("Authorization") == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "invalid api token"})
+		return
+	}
+	var request cohereEmbedRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if request.Model == "" {
+		request.Model = cohereDefaultModel
+	}
+	// The input type is only optional for the models before v3.
+	inputTypeRequired := !strings.Contains(request.Model, "-v2.0")
+	response, err := cohereEmbed(&request, inputTypeRequired)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	tokens := 0
+	counter := tokenizer.ForModel(request.Model)
+	for _, text := range request.Texts {
+		tokens += counter.Count(text)
+	}
+	response.Meta = gin.H{
+		"api_version":  gin.H{"version": "1"},
+		"billed_units": gin.H{"input_tokens": tokens},
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// cohereEmbed validates the request and returns the embeddings of its texts, also for the Cohere
+// models on Bedrock. Without embedding types the embeddings are float vectors, otherwise they are
+// the vectors of every requested type.
+func cohereEmbed(request *cohereEmbedRequest, inputTypeRequired bool) (*cohereEmbedResponse, error) {
+	if len(request.Texts) == 0 {
+		return nil, fmt.Errorf("texts must not be empty")
+	}
+	if len(request.Texts) > cohereMaxTexts {
+		return nil, fmt.Errorf("texts must have at most %d items", cohereMaxTexts)
+	}
+	if request.InputType == "" && inputTypeRequired {
+		return nil, fmt.Errorf("input_type is required for Embed v3 and newer models")
+	}
+	if request.InputType != "" && !contains(cohereInputTypes, request.InputType) {
+		return nil, fmt.Errorf("input_type must be one of %s", strings.Join(cohereInputTypes, ", "))
+	}
+	for _, embeddingType := range request.EmbeddingTypes {
+		if !contains(cohereEmbeddingTypes, embeddingType) {
+			return nil, fmt.Errorf("embedding_types must be among %s", strings.Join(cohereEmbeddingTypes, ", "))
+		}
+	}
+	dimensions, ok := cohereDimensions[request.Model]
+	if !ok {
+		dimensions = cohereDefaultDimensions
+	}
+
+	vectors := make([][]float64, 0, len(request.Texts))
+	for i, text := range request.Texts {
+		if text == "" {
+			return nil, fmt.Errorf("texts[%d] must not be empty", i)
+		}
+		vectors = append(vectors, currentEmbedder.embed(text, dimensions))
+	}
+	response := &cohereEmbedResponse{Id: mockId, Texts: request.Texts}
+	if len(request.EmbeddingTypes) == 0 {
+		response.ResponseType = cohereResponseFloats
+		response.Embeddings = vectors
+		return response, nil
+	}
+	byType := make(map[string][]interface{}, len(request.EmbeddingTypes))
+	for _, embeddingType := range request.EmbeddingTypes {
+		for _, vector := range vectors {
+			byType[embeddingType] = append(byType[embeddingType], quantize(vector, embeddingType))
+		}
+	}
+	response.ResponseType = cohereResponseByType
+	response.Embeddings = byType
+	return response, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type cohereEmbedRequest struct {
+	Texts          []string `json:"texts"`
+	Model          string   `json:"model,omitempty"`
+	InputType      string   `json:"input_type,omitempty"`
+	EmbeddingTypes []string `json:"embedding_types,omitempty"`
+	Truncate       string   `json:"truncate,omitempty"`
+}
+
+type cohereEmbedResponse struct {
+	Id    string   `json:"id"`
+	Texts []string `json:"texts"`
+	// Embeddings are float vectors, or the vectors of every embedding type.
+	Embeddings   interface{} `json:"embeddings"`
+	ResponseType string      `json:"response_type"`
+	// Meta is only returned by the Cohere API, not by Bedrock.
+	Meta gin.H `json:"meta,omitempty"`
+}
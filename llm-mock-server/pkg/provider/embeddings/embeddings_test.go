@@ -0,0 +1,214 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llm-mock-server/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		error    bool
+	}{
+		{name: "string", input: `"hello"`, expected: []string{"hello"}},
+		{name: "strings", input: `["a", "b"]`, expected: []string{"a", "b"}},
+		{name: "tokens", input: `[1, 2, 3]`, expected: []string{"1 2 3"}},
+		{name: "token arrays", input: `[[1], [2, 3]]`, expected: []string{"1", "2 3"}},
+		{name: "empty", input: `[]`, error: true},
+		{name: "empty string", input: `[""]`, error: true},
+		{name: "object", input: `{"text": "a"}`, error: true},
+		{name: "missing", input: ``, error: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := parseInput([]byte(tt.input))
+			if tt.error {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", inputs)
+				}
+				return
+			}
+			var texts []string
+			for _, input := range inputs {
+				texts = append(texts, input.text)
+			}
+			if err != nil || !reflect.DeepEqual(texts, tt.expected) {
+				t.Errorf("Expected %q, got %q (%v)", tt.expected, texts, err)
+			}
+		})
+	}
+}
+
+func TestVector(t *testing.T) {
+	v := vector("hello", 256)
+	if len(v) != 256 {
+		t.Fatalf("Expected 256 dimensions, got %d", len(v))
+	}
+	norm := 0.0
+	for _, value := range v {
+		norm += value * value
+	}
+	if math.Abs(norm-1) > 1e-9 {
+		t.Errorf("Expected a unit vector, got a norm of %f", math.Sqrt(norm))
+	}
+	if !reflect.DeepEqual(v, vector("hello", 256)) {
+		t.Error("Expected the same text to get the same vector")
+	}
+	if reflect.DeepEqual(v, vector("world", 256)) {
+		t.Error("Expected another text to get another vector")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encodeBase64(v))
+	if err != nil || len(data) != 4*256 {
+		t.Fatalf("Expected 256 base64 float32 values, got %d bytes (%v)", len(data), err)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(data)); got != float32(v[0]) {
+		t.Errorf("Expected the first value %f, got %f", v[0], got)
+	}
+}
+
+func TestSemantic(t *testing.T) {
+	e := newEmbedder(&config.EmbeddingsConfig{
+		Mode:     config.EmbeddingSemantic,
+		Synonyms: [][]string{{"reset my password", "change my passcode"}},
+	})
+	similarity := func(a, b string) float64 {
+		va, vb := e.embed(a, 512), e.embed(b, 512)
+		dot := 0.0
+		for i := range va {
+			dot += va[i] * vb[i]
+		}
+		return dot
+	}
+
+	near := similarity("How do I reset my password?", "how can i reset my password")
+	far := similarity("How do I reset my password?", "The weather is sunny in Paris")
+	if near < 0.6 || far > 0.3 {
+		t.Errorf("Expected similar texts to be close and unrelated texts far, got %f and %f", near, far)
+	}
+	if similarity("ai 网关的限流", "ai 网关限流") < similarity("ai 网关的限流", "天气晴朗") {
+		t.Error("Expected Chinese texts sharing characters to be closer")
+	}
+	if got := similarity("please change my passcode", "Please reset my password!"); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected synonyms to get the same vector, got a similarity of %f", got)
+	}
+	if !reflect.DeepEqual(e.embed("hello world", 64), e.embed("hello world", 64)) {
+		t.Error("Expected the same text to get the same vector")
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	values := []float64{0.5, -0.25, 0, 1, -1, 0.1, 0.2, -0.3, 0.4}
+	tests := []struct {
+		embeddingType string
+		expected      interface{}
+	}{
+		{embeddingType: typeFloat, expected: values},
+		{embeddingType: typeInt8, expected: []int{64, -32, 0, 127, -127, 13, 25, -38, 51}},
+		{embeddingType: typeUint8, expected: []int{192, 96, 128, 255, 1, 141, 153, 90, 179}},
+		{embeddingType: typeUbinary, expected: []int{0b10010110, 0b10000000}},
+		{embeddingType: typeBinary, expected: []int{0b10010110 - 128, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.embeddingType, func(t *testing.T) {
+			if got := quantize(values, tt.embeddingType); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestModelAndAction(t *testing.T) {
+	tests := []struct {
+		path   string
+		marker string
+		model  string
+		action string
+	}{
+		{path: "/v1beta/models/text-embedding-004:embedContent", marker: geminiPath, model: "text-embedding-004", action: "embedContent"},
+		{path: "/v1/publishers/google/models/text-embedding-005:predict", marker: vertexPathFragment, model: "text-embedding-005", action: "predict"},
+		{path: "/v1/projects/p/locations/us-central1/publishers/google/models/gemini-embedding-001:predict", marker: vertexPathFragment, model: "gemini-embedding-001", action: "predict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if model, action := modelAndAction(tt.path, tt.marker); model != tt.model || action != tt.action {
+				t.Errorf("Expected %s:%s, got %s:%s", tt.model, tt.action, model, action)
+			}
+		})
+	}
+}
+
+func TestCohereEmbed(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      cohereEmbedRequest
+		responseType string
+		error        bool
+	}{
+		{name: "floats", request: cohereEmbedRequest{Texts: []string{"a"}, Model: "embed-english-v3.0", InputType: "search_query"}, responseType: cohereResponseFloats},
+		{name: "by type", request: cohereEmbedRequest{Texts: []string{"a"}, InputType: "clustering", EmbeddingTypes: []string{typeInt8}}, responseType: cohereResponseByType},
+		{name: "missing input type", request: cohereEmbedRequest{Texts: []string{"a"}}, error: true},
+		{name: "unknown input type", request: cohereEmbedRequest{Texts: []string{"a"}, InputType: "query"}, error: true},
+		{name: "unknown embedding type", request: cohereEmbedRequest{Texts: []string{"a"}, InputType: "clustering", EmbeddingTypes: []string{"int4"}}, error: true},
+		{name: "no texts", request: cohereEmbedRequest{InputType: "clustering"}, error: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := cohereEmbed(&tt.request, true)
+			if tt.error {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", response)
+				}
+				return
+			}
+			if err != nil || response.ResponseType != tt.responseType {
+				t.Errorf("Expected a %s response, got %+v (%v)", tt.responseType, response, err)
+			}
+		})
+	}
+}
+
+func TestModelActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	server.POST("/v1beta/models/:modelAndAction", ModelActions(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "chat")
+	})
+
+	tests := []struct {
+		name string
+		path string
+		chat bool
+	}{
+		{name: "embed content", path: "/v1beta/models/text-embedding-004:embedContent"},
+		{name: "batch embed contents", path: "/v1beta/models/text-embedding-004:batchEmbedContents"},
+		{name: "generate content", path: "/v1beta/models/gemini-2.0-flash:generateContent", chat: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"content":{"parts":[{"text":"hello"}]}}`
+			if strings.HasSuffix(tt.path, "batchEmbedContents") {
+				body = `{"requests":[{"model":"models/text-embedding-004","content":{"parts":[{"text":"hello"}]}}]}`
+			}
+			request := httptest.NewRequest(http.MethodPost, tt.path+"?key=test", strings.NewReader(body))
+			request.Host = geminiDomain
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			if served := recorder.Body.String() == "chat"; served != tt.chat || recorder.Code != http.StatusOK {
+				t.Errorf("Expected the chat handler to serve it: %t, got %d %s", tt.chat, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package embeddings
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	geminiDomain = "generativelanguage.googleapis.com"
+	geminiPath   = "/v1beta/models/"
+
+	geminiActionEmbed      = "embedContent"
+	geminiActionBatchEmbed = "batchEmbedContents"
+
+	// geminiMaxBatch is the maximum number of requests of a batchEmbedContents call.
+	geminiMaxBatch = 100
+)
+
+// geminiDimensions are the default dimensions of the Gemini embedding models. Other models get
+// geminiDefaultDimensions.
+var geminiDimensions = map[string]int{
+	"gemini-embedding-001": 3072,
+	"text-embedding-004":   768,
+	"embedding-001":        768,
+}
+
+const geminiDefaultDimensions = 768
+
+type geminiProvider struct{}
+
+func (p *geminiProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	path := ctx.Request.URL.Path
+	return ctx.Request.Host == geminiDomain &&
+		strings.HasPrefix(path, geminiPath) &&
+		(strings.HasSuffix(path, ":"+geminiActionEmbed) ||
+			strings.HasSuffix(path, ":"+geminiActionBatchEmbed))
+}
+
+func (p *geminiProvider) HandleEmbeddings(ctx *gin.Context) {
+	if ctx.GetHeader("x-goog-api-key") == "" && ctx.Query("key") == "" {
+		sendGoogleError(ctx, http.StatusForbidden, "Method doesn't allow unregistered callers (callers without established identity). Please use API Key or other form of API consumer identity to call this API.")
+		return
+	}
+	model, action := modelAndAction(ctx.Request.URL.Path, geminiPath)
+
+	if action == geminiActionEmbed {
+		var request geminiEmbedRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+		embedding, err := p.embed(model, &request)
+		if err != nil {
+			sendGoogleError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, geminiEmbedResponse{Embedding: embedding})
+		return
+	}
+
+	var request geminiBatchEmbedRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if len(request.Requests) == 0 {
+		sendGoogleError(ctx, http.StatusBadRequest, "requests must not be empty")
+		return
+	}
+	if len(request.Requests) > geminiMaxBatch {
+		sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("at most %d requests can be in one batch", geminiMaxBatch))
+		return
+	}
+	embeddings := make([]geminiEmbedding, 0, len(request.Requests))
+	for i := range request.Requests {
+		// Every request names the model, which must be the model of the batch.
+		if name := request.Requests[i].Model; name != "models/"+model {
+			sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("Model name %q of request %d does not match the model of the batch: models/%s", name, i, model))
+			return
+		}
+		embedding, err := p.embed(model, &request.Requests[i])
+		if err != nil {
+			sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("requests[%d]: %v", i, err))
+			return
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	ctx.JSON(http.StatusOK, geminiBatchEmbedResponse{Embeddings: embeddings})
+}
+
+func (p *geminiProvider) embed(model string, request *geminiEmbedRequest) (geminiEmbedding, error) {
+	text := request.Content.text()
+	if text == "" {
+		return geminiEmbedding{}, fmt.Errorf("content must have a text part")
+	}
+	dimensions, ok := geminiDimensions[model]
+	if !ok {
+		dimensions = geminiDefaultDimensions
+	}
+	if request.OutputDimensionality != nil {
+		if *request.OutputDimensionality < 1 || *request.OutputDimensionality > dimensions {
+			return geminiEmbedding{}, fmt.Errorf("outputDimensionality must be between 1 and %d", dimensions)
+		}
+		dimensions = *request.OutputDimensionality
+	}
+	return geminiEmbedding{Values: currentEmbedder.embed(text, dimensions)}, nil
+}
+
+// modelAndAction splits the "{model}:{action}" that follows the marker in the path of the Google
+// APIs, e.g. "/v1beta/models/text-embedding-004:embedContent".
+func modelAndAction(path, marker string) (string, string) {
+	if i := strings.Index(path, marker); i >= 0 {
+		path = path[i+len(marker):]
+	}
+	model, action, _ := strings.Cut(path, ":")
+	return model, action
+}
+
+// sendGoogleError sends the error of the Gemini and Vertex APIs, which name the google.rpc.Code of
+// the status.
+func sendGoogleError(ctx *gin.Context, statusCode int, message string) {
+	status := "INTERNAL"
+	switch statusCode {
+	case http.StatusBadRequest:
+		status = "INVALID_ARGUMENT"
+	case http.StatusForbidden:
+		status = "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		status = "NOT_FOUND"
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": gin.H{
+			"code":    statusCode,
+			"message": message,
+			"status":  status,
+		},
+	})
+}
+
+type geminiEmbedRequest struct {
+	Model                string        `json:"model,omitempty"`
+	Content              geminiContent `json:"content"`
+	TaskType             string        `json:"taskType,omitempty"`
+	Title                string        `json:"title,omitempty"`
+	OutputDimensionality *int          `json:"outputDimensionality,omitempty"`
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiEmbedRequest `json:"requests"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text,omitempty"`
+}
+
+// text returns the text of the parts, which are embedded together.
+func (c geminiContent) text() string {
+	texts := make([]string, 0, len(c.Parts))
+	for _, part := range c.Parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+type geminiEmbedding struct {
+	Values []float64 `json:"values"`
+}
+
+type geminiEmbedResponse struct {
+	Embedding geminiEmbedding `json:"embedding"`
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []geminiEmbedding `json:"embeddings"`
+}
@@ -0,0 +1,197 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	encodingFloat  = "float"
+	encodingBase64 = "base64"
+
+	// maxInputs is the maximum number of inputs of a request, like the real API.
+	maxInputs = 2048
+)
+
+// openAiDimensions are the default dimensions of the OpenAI embedding models. Other models get
+// defaultDimensions.
+var openAiDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+const defaultDimensions = 1536
+
+type openAiProvider struct{}
+
+func (p *openAiProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return true
+}
+
+func (p *openAiProvider) HandleEmbeddings(ctx *gin.Context) {
+	var request embeddingsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "")
+		return
+	}
+	if request.Model == "" {
+		sendOpenAiError(ctx, http.StatusBadRequest, "you must provide a model parameter", "model")
+		return
+	}
+	inputs, err := parseInput(request.Input)
+	if err != nil {
+		sendOpenAiError(ctx, http.StatusBadRequest, err.Error(), "input")
+		return
+	}
+	dimensions, ok := openAiDimensions[request.Model]
+	if !ok {
+		dimensions = defaultDimensions
+	}
+	if request.Dimensions != nil {
+		if request.Model == "text-embedding-ada-002" {
+			sendOpenAiError(ctx, http.StatusBadRequest, "This model does not support specifying dimensions.", "dimensions")
+			return
+		}
+		if *request.Dimensions < 1 || *request.Dimensions > dimensions {
+			sendOpenAiError(ctx, http.StatusBadRequest, fmt.Sprintf("dimensions must be between 1 and %d", dimensions), "dimensions")
+			return
+		}
+		dimensions = *request.Dimensions
+	}
+	encoding := request.EncodingFormat
+	if encoding == "" {
+		encoding = encodingFloat
+	}
+	if encoding != encodingFloat && encoding != encodingBase64 {
+		sendOpenAiError(ctx, http.StatusBadRequest, fmt.Sprintf("encoding_format must be %q or %q", encodingFloat, encodingBase64), "encoding_format")
+		return
+	}
+
+	counter := tokenizer.ForModel(request.Model)
+	data := make([]embeddingData, 0, len(inputs))
+	tokens := 0
+	for i, input := range inputs {
+		if input.tokens != nil {
+			tokens += len(input.tokens)
+		} else {
+			tokens += counter.Count(input.text)
+		}
+		values := currentEmbedder.embed(input.text, dimensions)
+		var embedding interface{} = values
+		if encoding == encodingBase64 {
+			embedding = encodeBase64(values)
+		}
+		data = append(data, embeddingData{Object: "embedding", Index: i, Embedding: embedding})
+	}
+	ctx.JSON(http.StatusOK, embeddingsResponse{
+		Object: "list",
+		Data:   data,
+		Model:  request.Model,
+		Usage:  embeddingsUsage{PromptTokens: tokens, TotalTokens: tokens},
+	})
+}
+
+// embeddingInput is an input of the request: a text, or an array of token ids, whose text is
+// then the ids joined, so that it still gets a stable embedding.
+type embeddingInput struct {
+	text   string
+	tokens []int
+}
+
+// parseInput parses the input of the request, which is a string, an array of strings, an array of
+// token ids or an array of token id arrays.
+func parseInput(raw json.RawMessage) ([]embeddingInput, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("you must provide an input parameter")
+	}
+	var inputs []embeddingInput
+	var text string
+	var texts []string
+	var tokens []int
+	var tokenArrays [][]int
+	switch {
+	case json.Unmarshal(raw, &text) == nil:
+		inputs = []embeddingInput{{text: text}}
+	case json.Unmarshal(raw, &texts) == nil:
+		for _, text := range texts {
+			inputs = append(inputs, embeddingInput{text: text})
+		}
+	case json.Unmarshal(raw, &tokens) == nil:
+		inputs = []embeddingInput{tokenInput(tokens)}
+	case json.Unmarshal(raw, &tokenArrays) == nil:
+		for _, tokens := range tokenArrays {
+			inputs = append(inputs, tokenInput(tokens))
+		}
+	default:
+		return nil, fmt.Errorf("input must be a string, an array of strings, an array of tokens or an array of token arrays")
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("input must not be empty")
+	}
+	if len(inputs) > maxInputs {
+		return nil, fmt.Errorf("input must have at most %d items", maxInputs)
+	}
+	for i, input := range inputs {
+		if input.text == "" {
+			return nil, fmt.Errorf("input %d must not be empty", i)
+		}
+	}
+	return inputs, nil
+}
+
+func tokenInput(tokens []int) embeddingInput {
+	ids := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		ids = append(ids, fmt.Sprint(token))
+	}
+	return embeddingInput{text: strings.Join(ids, " "), tokens: tokens}
+}
+
+func sendOpenAiError(ctx *gin.Context, statusCode int, message, param string) {
+	var errorParam interface{}
+	if param != "" {
+		errorParam = param
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    "invalid_request_error",
+			"param":   errorParam,
+			"code":    nil,
+		},
+	})
+}
+
+type embeddingsRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`
+	Dimensions     *int            `json:"dimensions,omitempty"`
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+type embeddingsResponse struct {
+	Object string          `json:"object"`
+	Data   []embeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  embeddingsUsage `json:"usage"`
+}
+
+type embeddingData struct {
+	Object string `json:"object"`
+	Index  int    `json:"index"`
+	// Embedding is a list of floats, or a base64 string with the base64 encoding format.
+	Embedding interface{} `json:"embedding"`
+}
+
+type embeddingsUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
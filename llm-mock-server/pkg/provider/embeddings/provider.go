@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"llm-mock-server/pkg/config"
 	"llm-mock-server/pkg/provider"
 )
 
@@ -13,10 +14,66 @@ type requestHandler interface {
 	HandleEmbeddings(context *gin.Context)
 }
 
-var chatCompletionsHandlers []requestHandler
+// mockId is the id or request id of the responses that carry one.
+const mockId = "embd-llm-mock"
+
+var (
+	embeddingsHandlers = []requestHandler{
+		&bedrockProvider{},
+		&qwenProvider{},
+		&cohereProvider{},
+		&openAiProvider{}, // As the last fallback
+	}
+
+	// modelActionHandlers serve the embeddings actions of the Gemini and Vertex model routes, which
+	// are registered by the chat package together with the generation actions, see ModelActions.
+	modelActionHandlers = []requestHandler{
+		&geminiProvider{},
+		&vertexProvider{},
+	}
+
+	embeddingsRoutes = []string{
+		// openai
+		"/v1/embeddings",
+		// cohere
+		"/v1/embed",
+		// qwen
+		"/api/v1/services/embeddings/text-embedding/text-embedding",
+		// bedrock (InvokeModel for the Titan and Cohere embedding models)
+		"/model/:modelId/invoke",
+	}
+)
+
+// currentEmbedder computes the embeddings with the mode of the configuration.
+var currentEmbedder = defaultEmbedder
+
+// SetupRoutes registers the embeddings routes, which compute the embeddings as configured.
+func SetupRoutes(server *gin.Engine, cfg *config.Config) {
+	if cfg != nil && cfg.Embeddings != nil {
+		currentEmbedder = newEmbedder(cfg.Embeddings)
+	}
+	for _, route := range embeddingsRoutes {
+		server.POST(route, HandleEmbeddings)
+	}
+}
+
+// ModelActions returns a handler that serves the embeddings actions of the Gemini and Vertex model
+// routes, such as :embedContent or :predict, and lets the other actions through to the chat
+// handler. It is meant to be passed to chat.SetupRoutes, which puts it in front of those routes.
+func ModelActions() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		for _, handler := range modelActionHandlers {
+			if handler.ShouldHandleRequest(context) {
+				handler.HandleEmbeddings(context)
+				context.Abort()
+				return
+			}
+		}
+	}
+}
 
 func HandleEmbeddings(context *gin.Context) {
-	for _, handler := range chatCompletionsHandlers {
+	for _, handler := range embeddingsHandlers {
 		if handler.ShouldHandleRequest(context) {
 			handler.HandleEmbeddings(context)
 			return
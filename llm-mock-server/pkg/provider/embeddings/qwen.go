@@ -0,0 +1,177 @@
+package embeddings
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	qwenDomain        = "dashscope.aliyuncs.com"
+	qwenEmbeddingPath = "/api/v1/services/embeddings/text-embedding/text-embedding"
+
+	qwenOutputDense       = "dense"
+	qwenOutputSparse      = "sparse"
+	qwenOutputDenseSparse = "dense&sparse"
+
+	// qwenSparseVocabulary is the size of the vocabulary the sparse embeddings index.
+	qwenSparseVocabulary = 250002
+)
+
+// qwenModel describes a DashScope text embedding model.
+type qwenModel struct {
+	// dimensions are the supported dimensions, the first one being the default.
+	dimensions []int
+	maxTexts   int
+}
+
+var qwenModels = map[string]qwenModel{
+	"text-embedding-v1": {dimensions: []int{1536}, maxTexts: 25},
+	"text-embedding-v2": {dimensions: []int{1536}, maxTexts: 25},
+	"text-embedding-v3": {dimensions: []int{1024, 768, 512, 256, 128, 64}, maxTexts: 10},
+	"text-embedding-v4": {dimensions: []int{1024, 2048, 1536, 768, 512, 256, 128, 64}, maxTexts: 10},
+}
+
+type qwenProvider struct{}
+
+func (p *qwenProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return ctx.Request.Host == qwenDomain && ctx.Request.URL.Path == qwenEmbeddingPath
+}
+
+func (p *qwenProvider) HandleEmbeddings(ctx *gin.Context) {
+	if ctx.GetHeader("Authorization") == "" {
+		sendQwenError(ctx, http.StatusUnauthorized, "InvalidApiKey", "No API-key provided.")
+		return
+	}
+	var request qwenEmbeddingRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+	model, ok := qwenModels[request.Model]
+	if !ok {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", "Model not exist.")
+		return
+	}
+	texts := request.Input.Texts
+	if len(texts) == 0 {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", "input.texts should not be empty")
+		return
+	}
+	if len(texts) > model.maxTexts {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("batch size is invalid, it should not be larger than %d.", model.maxTexts))
+		return
+	}
+	dimensions := model.dimensions[0]
+	if request.Parameters.Dimension != nil {
+		if !containsInt(model.dimensions, *request.Parameters.Dimension) {
+			sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("Value error, dimension must be one of %v", model.dimensions))
+			return
+		}
+		dimensions = *request.Parameters.Dimension
+	}
+	output := request.Parameters.OutputType
+	if output == "" {
+		output = qwenOutputDense
+	}
+	if output != qwenOutputDense && output != qwenOutputSparse && output != qwenOutputDenseSparse {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("output_type must be %s, %s or %s", qwenOutputDense, qwenOutputSparse, qwenOutputDenseSparse))
+		return
+	}
+
+	counter := tokenizer.ForModel(request.Model)
+	embeddings := make([]qwenEmbedding, 0, len(texts))
+	tokens := 0
+	for i, text := range texts {
+		if text == "" {
+			sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("input.texts[%d] should not be empty", i))
+			return
+		}
+		tokens += counter.Count(text)
+		embedding := qwenEmbedding{TextIndex: i}
+		if output != qwenOutputSparse {
+			embedding.Embedding = currentEmbedder.embed(text, dimensions)
+		}
+		if output != qwenOutputDense {
+			embedding.SparseEmbedding = sparseEmbedding(text)
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	ctx.JSON(http.StatusOK, qwenEmbeddingResponse{
+		Output:    qwenEmbeddingOutput{Embeddings: embeddings},
+		Usage:     qwenEmbeddingUsage{TotalTokens: tokens},
+		RequestId: mockId,
+	})
+}
+
+// sparseEmbedding returns the sparse embedding of the text: a weight for every distinct word, at
+// the index of its hash in the vocabulary.
+func sparseEmbedding(text string) []qwenSparseValue {
+	var values []qwenSparseValue
+	seen := make(map[string]int)
+	for _, word := range tokenizer.Words(text) {
+		if i, ok := seen[word]; ok {
+			values[i].Value += 0.1
+			continue
+		}
+		hash := fnv.New32a()
+		hash.Write([]byte(word))
+		seen[word] = len(values)
+		values = append(values, qwenSparseValue{
+			Index: int(hash.Sum32() % qwenSparseVocabulary),
+			Value: 0.1 + float64(len([]rune(word)))/100,
+			Token: word,
+		})
+	}
+	return values
+}
+
+func sendQwenError(ctx *gin.Context, statusCode int, code, message string) {
+	ctx.JSON(statusCode, gin.H{
+		"code":       code,
+		"message":    message,
+		"request_id": mockId,
+	})
+}
+
+type qwenEmbeddingRequest struct {
+	Model string `json:"model"`
+	Input struct {
+		Texts []string `json:"texts"`
+	} `json:"input"`
+	Parameters struct {
+		Dimension  *int   `json:"dimension,omitempty"`
+		TextType   string `json:"text_type,omitempty"`
+		OutputType string `json:"output_type,omitempty"`
+	} `json:"parameters"`
+}
+
+type qwenEmbeddingResponse struct {
+	Output    qwenEmbeddingOutput `json:"output"`
+	Usage     qwenEmbeddingUsage  `json:"usage"`
+	RequestId string              `json:"request_id"`
+}
+
+type qwenEmbeddingOutput struct {
+	Embeddings []qwenEmbedding `json:"embeddings"`
+}
+
+type qwenEmbedding struct {
+	TextIndex       int               `json:"text_index"`
+	Embedding       []float64         `json:"embedding,omitempty"`
+	SparseEmbedding []qwenSparseValue `json:"sparse_embedding,omitempty"`
+}
+
+type qwenSparseValue struct {
+	Index int     `json:"index"`
+	Value float64 `json:"value"`
+	Token string  `json:"token"`
+}
+
+type qwenEmbeddingUsage struct {
+	TotalTokens int `json:"total_tokens"`
+}
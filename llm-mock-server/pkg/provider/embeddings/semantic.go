@@ -0,0 +1,145 @@
+package embeddings
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"llm-mock-server/pkg/config"
+	"llm-mock-server/pkg/tokenizer"
+)
+
+// Weights of the features of the semantic embeddings: the words dominate, the word pairs reward
+// the same word order and the character trigrams make inflections such as "password" and
+// "passwords" close.
+const (
+	wordWeight    = 1.0
+	pairWeight    = 0.5
+	trigramWeight = 0.3
+)
+
+// synonymPrefix marks the word a synonym phrase is replaced with. It cannot appear in a tokenized
+// text, which only has letters and digits.
+const synonymPrefix = "\x00synonym"
+
+// embedder computes the embeddings of the texts with the configured mode.
+type embedder struct {
+	semantic bool
+	// synonyms maps the words of the synonym phrases to the group they belong to, the longest
+	// phrases first so that they win over the phrases they contain.
+	synonyms []synonym
+}
+
+type synonym struct {
+	words []string
+	group string
+}
+
+var defaultEmbedder = newEmbedder(nil)
+
+func newEmbedder(cfg *config.EmbeddingsConfig) *embedder {
+	e := &embedder{}
+	if cfg == nil {
+		return e
+	}
+	e.semantic = cfg.Mode == config.EmbeddingSemantic
+	for i, group := range cfg.Synonyms {
+		for _, phrase := range group {
+			if words := tokenizer.Words(phrase); len(words) > 0 {
+				e.synonyms = append(e.synonyms, synonym{words: words, group: synonymPrefix + strconv.Itoa(i)})
+			}
+		}
+	}
+	sort.SliceStable(e.synonyms, func(i, j int) bool {
+		return len(e.synonyms[i].words) > len(e.synonyms[j].words)
+	})
+	return e
+}
+
+// embed returns the embedding of the text. In the random mode the texts get unrelated vectors. In
+// the semantic mode the vector is the weighted sum of a pseudo-random direction per feature of the
+// text, so that the cosine similarity of two texts follows the features they share.
+func (e *embedder) embed(text string, dimensions int) []float64 {
+	if !e.semantic {
+		return vector(text, dimensions)
+	}
+	features := e.features(text)
+	if len(features) == 0 {
+		return vector(text, dimensions)
+	}
+	// Sum the features in a fixed order, so that the same text gets the same bits.
+	names := make([]string, 0, len(features))
+	for feature := range features {
+		names = append(names, feature)
+	}
+	sort.Strings(names)
+	values := make([]float64, dimensions)
+	for _, feature := range names {
+		weight := features[feature]
+		hash := fnv.New64a()
+		hash.Write([]byte(feature))
+		random := rand.New(rand.NewSource(int64(hash.Sum64())))
+		for i := range values {
+			values[i] += weight * random.NormFloat64()
+		}
+	}
+	return normalize(values)
+}
+
+// features returns the weighted features of the text: its words, with the synonym phrases replaced
+// by their group, its word pairs and the character trigrams of its words.
+func (e *embedder) features(text string) map[string]float64 {
+	words := e.replaceSynonyms(tokenizer.Words(text))
+	features := make(map[string]float64)
+	for i, word := range words {
+		features["w:"+word] += wordWeight
+		if i > 0 {
+			features["p:"+words[i-1]+" "+word] += pairWeight
+		}
+		if strings.HasPrefix(word, synonymPrefix) || len([]rune(word)) == 1 {
+			continue
+		}
+		runes := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(runes); j++ {
+			features["t:"+string(runes[j:j+3])] += trigramWeight
+		}
+	}
+	return features
+}
+
+func (e *embedder) replaceSynonyms(words []string) []string {
+	if len(e.synonyms) == 0 {
+		return words
+	}
+	replaced := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		matched := false
+		for _, synonym := range e.synonyms {
+			if hasWordsAt(words, i, synonym.words) {
+				replaced = append(replaced, synonym.group)
+				i += len(synonym.words)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			replaced = append(replaced, words[i])
+			i++
+		}
+	}
+	return replaced
+}
+
+func hasWordsAt(words []string, i int, phrase []string) bool {
+	if i+len(phrase) > len(words) {
+		return false
+	}
+	for j, word := range phrase {
+		if words[i+j] != word {
+			return false
+		}
+	}
+	return true
+}
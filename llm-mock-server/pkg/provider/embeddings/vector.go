@@ -0,0 +1,93 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+// vector returns the embedding of the text: a unit vector of the given dimensions, seeded with
+// the hash of the text, so that the same text always gets the same embedding.
+func vector(text string, dimensions int) []float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+	values := make([]float64, dimensions)
+	for i := range values {
+		values[i] = random.NormFloat64()
+	}
+	return normalize(values)
+}
+
+// normalize scales the vector to unit length, the way the real embeddings are returned.
+func normalize(values []float64) []float64 {
+	norm := 0.0
+	for _, value := range values {
+		norm += value * value
+	}
+	if norm == 0 {
+		return values
+	}
+	norm = math.Sqrt(norm)
+	for i := range values {
+		values[i] /= norm
+	}
+	return values
+}
+
+// encodeBase64 encodes the vector as base64 little-endian float32 values, the encoding_format
+// "base64" of the OpenAI API.
+func encodeBase64(values []float64) string {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(float32(value)))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Embedding types of the APIs that return quantized embeddings besides the floats.
+const (
+	typeFloat   = "float"
+	typeInt8    = "int8"
+	typeUint8   = "uint8"
+	typeBinary  = "binary"
+	typeUbinary = "ubinary"
+)
+
+// quantize converts the vector to an embedding type: int8 and uint8 scale the values to a byte,
+// binary and ubinary pack the signs of 8 values in a signed or unsigned byte, the way Cohere does.
+func quantize(values []float64, embeddingType string) interface{} {
+	switch embeddingType {
+	case typeInt8, typeUint8:
+		peak := 0.0
+		for _, value := range values {
+			peak = math.Max(peak, math.Abs(value))
+		}
+		quantized := make([]int, len(values))
+		for i, value := range values {
+			if peak > 0 {
+				quantized[i] = int(math.Round(value / peak * 127))
+			}
+			if embeddingType == typeUint8 {
+				quantized[i] += 128
+			}
+		}
+		return quantized
+	case typeBinary, typeUbinary:
+		packed := make([]int, (len(values)+7)/8)
+		for i, value := range values {
+			if value > 0 {
+				packed[i/8] |= 1 << (7 - i%8)
+			}
+		}
+		if embeddingType == typeBinary {
+			for i := range packed {
+				packed[i] -= 128
+			}
+		}
+		return packed
+	}
+	return values
+}
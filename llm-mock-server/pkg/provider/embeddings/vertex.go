@@ -0,0 +1,130 @@
+package embeddings
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"llm-mock-server/pkg/tokenizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// vertexDomain and vertexPathFragment match both the Express Mode path
+	// (/v1/publishers/google/models/{model}:predict) and the standard path
+	// (/v1/projects/{project}/locations/{location}/publishers/google/models/{model}:predict).
+	vertexDomain        = "aiplatform.googleapis.com"
+	vertexPathFragment  = "/publishers/google/models/"
+	vertexActionPredict = "predict"
+
+	// vertexMaxInstances is the maximum number of instances of a predict call.
+	vertexMaxInstances = 250
+)
+
+// vertexDimensions are the default dimensions of the Vertex embedding models. Other models get
+// vertexDefaultDimensions.
+var vertexDimensions = map[string]int{
+	"gemini-embedding-001":            3072,
+	"text-embedding-005":              768,
+	"text-embedding-004":              768,
+	"text-multilingual-embedding-002": 768,
+}
+
+const vertexDefaultDimensions = 768
+
+type vertexProvider struct{}
+
+func (p *vertexProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	path := ctx.Request.URL.Path
+	return ctx.Request.Host == vertexDomain &&
+		strings.Contains(path, vertexPathFragment) &&
+		strings.HasSuffix(path, ":"+vertexActionPredict)
+}
+
+func (p *vertexProvider) HandleEmbeddings(ctx *gin.Context) {
+	// Auth is not enforced, like the Vertex chat mock: Express Mode and OAuth bearers are not checked.
+	model, _ := modelAndAction(ctx.Request.URL.Path, vertexPathFragment)
+
+	var request vertexPredictRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if len(request.Instances) == 0 {
+		sendGoogleError(ctx, http.StatusBadRequest, "instances must not be empty")
+		return
+	}
+	if len(request.Instances) > vertexMaxInstances {
+		sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("at most %d instances can be in one request", vertexMaxInstances))
+		return
+	}
+	dimensions, ok := vertexDimensions[model]
+	if !ok {
+		dimensions = vertexDefaultDimensions
+	}
+	if request.Parameters.OutputDimensionality != nil {
+		if *request.Parameters.OutputDimensionality < 1 || *request.Parameters.OutputDimensionality > dimensions {
+			sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("outputDimensionality must be between 1 and %d", dimensions))
+			return
+		}
+		dimensions = *request.Parameters.OutputDimensionality
+	}
+
+	counter := tokenizer.ForModel(model)
+	predictions := make([]vertexPrediction, 0, len(request.Instances))
+	characters := 0
+	for i, instance := range request.Instances {
+		if instance.Content == "" {
+			sendGoogleError(ctx, http.StatusBadRequest, fmt.Sprintf("instances[%d]: content must not be empty", i))
+			return
+		}
+		characters += utf8.RuneCountInString(instance.Content)
+		predictions = append(predictions, vertexPrediction{Embeddings: vertexEmbeddings{
+			Values:     currentEmbedder.embed(instance.Content, dimensions),
+			Statistics: vertexStatistics{TokenCount: counter.Count(instance.Content)},
+		}})
+	}
+	ctx.JSON(http.StatusOK, vertexPredictResponse{
+		Predictions: predictions,
+		Metadata:    vertexMetadata{BillableCharacterCount: characters},
+	})
+}
+
+type vertexPredictRequest struct {
+	Instances  []vertexInstance `json:"instances"`
+	Parameters struct {
+		AutoTruncate         *bool `json:"autoTruncate,omitempty"`
+		OutputDimensionality *int  `json:"outputDimensionality,omitempty"`
+	} `json:"parameters"`
+}
+
+type vertexInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+	Title    string `json:"title,omitempty"`
+}
+
+type vertexPredictResponse struct {
+	Predictions []vertexPrediction `json:"predictions"`
+	Metadata    vertexMetadata     `json:"metadata"`
+}
+
+type vertexPrediction struct {
+	Embeddings vertexEmbeddings `json:"embeddings"`
+}
+
+type vertexEmbeddings struct {
+	Values     []float64        `json:"values"`
+	Statistics vertexStatistics `json:"statistics"`
+}
+
+type vertexStatistics struct {
+	Truncated  bool `json:"truncated"`
+	TokenCount int  `json:"token_count"`
+}
+
+type vertexMetadata struct {
+	BillableCharacterCount int `json:"billableCharacterCount"`
+}
@@ -0,0 +1,62 @@
+package rerank
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cohereV1Path = "/v1/rerank"
+	cohereV2Path = "/v2/rerank"
+
+	// cohereSearchUnitDocuments is the number of documents billed as one search unit.
+	cohereSearchUnitDocuments = 100
+)
+
+type cohereProvider struct{}
+
+func (p *cohereProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return true
+}
+
+func (p *cohereProvider) HandleRerank(ctx *gin.Context) {
+	// The real Cohere API requires "Authorization: Bearer <api key>"; ai-proxy always injects it.
+	if ctx.GetHeader("Authorization") == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "invalid api token"})
+		return
+	}
+	var request rerankRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	version := "1"
+	if ctx.Request.URL.Path == cohereV2Path {
+		version = "2"
+		if request.Model == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid request: model is required"})
+			return
+		}
+	}
+	documents, err := parseDocuments(request.Documents)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	results, err := rerank(request.Query, documents, request.TopN, request.ReturnDocuments != nil && *request.ReturnDocuments)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	searchUnits := (len(documents) + cohereSearchUnitDocuments - 1) / cohereSearchUnitDocuments
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":      mockId,
+		"results": results,
+		"meta": gin.H{
+			"api_version":  gin.H{"version": version},
+			"billed_units": gin.H{"search_units": searchUnits},
+		},
+	})
+}
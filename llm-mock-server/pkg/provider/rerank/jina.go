@@ -0,0 +1,51 @@
+package rerank
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	jinaDomain     = "api.jina.ai"
+	jinaRerankPath = "/v1/rerank"
+)
+
+type jinaProvider struct{}
+
+func (p *jinaProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return ctx.Request.Host == jinaDomain && ctx.Request.URL.Path == jinaRerankPath
+}
+
+func (p *jinaProvider) HandleRerank(ctx *gin.Context) {
+	if ctx.GetHeader("Authorization") == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"detail": "No API key provided"})
+		return
+	}
+	var request rerankRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	if request.Model == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"detail": "model is required"})
+		return
+	}
+	documents, err := parseDocuments(request.Documents)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	// Jina returns the documents unless asked not to.
+	results, err := rerank(request.Query, documents, request.TopN, request.ReturnDocuments == nil || *request.ReturnDocuments)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"model":   request.Model,
+		"usage":   gin.H{"total_tokens": countTokens(request.Model, request.Query, documents)},
+		"results": results,
+	})
+}
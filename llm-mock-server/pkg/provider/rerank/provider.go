@@ -0,0 +1,51 @@
+package rerank
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"llm-mock-server/pkg/provider"
+)
+
+type requestHandler interface {
+	provider.CommonRequestHandler
+
+	HandleRerank(context *gin.Context)
+}
+
+// mockId is the id or request id of the responses that carry one.
+const mockId = "rerank-llm-mock"
+
+var (
+	rerankHandlers = []requestHandler{
+		&jinaProvider{},
+		&qwenProvider{},
+		&cohereProvider{}, // As the last fallback
+	}
+
+	rerankRoutes = []string{
+		// cohere, jina
+		"/v1/rerank",
+		// cohere
+		"/v2/rerank",
+		// qwen (gte-rerank)
+		"/api/v1/services/rerank/text-rerank/text-rerank",
+	}
+)
+
+// SetupRoutes registers the rerank routes.
+func SetupRoutes(server *gin.Engine) {
+	for _, route := range rerankRoutes {
+		server.POST(route, HandleRerank)
+	}
+}
+
+func HandleRerank(context *gin.Context) {
+	for _, handler := range rerankHandlers {
+		if handler.ShouldHandleRequest(context) {
+			handler.HandleRerank(context)
+			return
+		}
+	}
+	context.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+}
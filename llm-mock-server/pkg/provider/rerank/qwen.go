@@ -0,0 +1,72 @@
+package rerank
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	qwenDomain     = "dashscope.aliyuncs.com"
+	qwenRerankPath = "/api/v1/services/rerank/text-rerank/text-rerank"
+)
+
+type qwenProvider struct{}
+
+func (p *qwenProvider) ShouldHandleRequest(ctx *gin.Context) bool {
+	return ctx.Request.Host == qwenDomain && ctx.Request.URL.Path == qwenRerankPath
+}
+
+func (p *qwenProvider) HandleRerank(ctx *gin.Context) {
+	if ctx.GetHeader("Authorization") == "" {
+		sendQwenError(ctx, http.StatusUnauthorized, "InvalidApiKey", "No API-key provided.")
+		return
+	}
+	var request qwenRerankRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+	if request.Model == "" {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", "Model can not be empty.")
+		return
+	}
+	documents, err := parseDocuments(request.Input.Documents)
+	if err != nil {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+	returnDocuments := request.Parameters.ReturnDocuments != nil && *request.Parameters.ReturnDocuments
+	results, err := rerank(request.Input.Query, documents, request.Parameters.TopN, returnDocuments)
+	if err != nil {
+		sendQwenError(ctx, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"output":     gin.H{"results": results},
+		"usage":      gin.H{"total_tokens": countTokens(request.Model, request.Input.Query, documents)},
+		"request_id": mockId,
+	})
+}
+
+func sendQwenError(ctx *gin.Context, statusCode int, code, message string) {
+	ctx.JSON(statusCode, gin.H{
+		"code":       code,
+		"message":    message,
+		"request_id": mockId,
+	})
+}
+
+type qwenRerankRequest struct {
+	Model string `json:"model"`
+	Input struct {
+		Query     string            `json:"query"`
+		Documents []json.RawMessage `json:"documents"`
+	} `json:"input"`
+	Parameters struct {
+		TopN            *int  `json:"top_n,omitempty"`
+		ReturnDocuments *bool `json:"return_documents,omitempty"`
+	} `json:"parameters"`
+}
@@ -0,0 +1,87 @@
+package rerank
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRerank(t *testing.T) {
+	documents := []string{
+		"The weather in Paris is sunny.",
+		"Higress is an AI gateway with rate limiting.",
+		"An AI gateway routes LLM traffic.",
+		"Higress 是一个 AI 网关",
+	}
+	topTwo := 2
+	tests := []struct {
+		name     string
+		query    string
+		topN     *int
+		expected []int
+	}{
+		{name: "all", query: "AI gateway routes", expected: []int{2, 1, 3, 0}},
+		{name: "top n", query: "AI gateway routes", topN: &topTwo, expected: []int{2, 1}},
+		{name: "chinese", query: "AI 网关", expected: []int{3, 2, 1, 0}},
+		{name: "no overlap keeps the order", query: "kubernetes", expected: []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := rerank(tt.query, documents, tt.topN, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var indexes []int
+			for _, result := range results {
+				indexes = append(indexes, result.Index)
+				if result.RelevanceScore < 0 || result.RelevanceScore > 1 || result.Document != nil {
+					t.Errorf("Expected a score between 0 and 1 without the document, got %+v", result)
+				}
+			}
+			if !reflect.DeepEqual(indexes, tt.expected) {
+				t.Errorf("Expected the order %v, got %v", tt.expected, indexes)
+			}
+		})
+	}
+
+	results, _ := rerank("gateway", documents, nil, true)
+	if results[0].Document == nil || results[0].Document.Text != documents[results[0].Index] {
+		t.Errorf("Expected the documents to be returned, got %+v", results[0])
+	}
+	zero := 0
+	if _, err := rerank("gateway", documents, &zero, false); err == nil {
+		t.Error("Expected top_n 0 to be rejected")
+	}
+}
+
+func TestParseDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents string
+		expected  []string
+		error     bool
+	}{
+		{name: "strings", documents: `["a", "b"]`, expected: []string{"a", "b"}},
+		{name: "objects", documents: `[{"text": "a"}, "b"]`, expected: []string{"a", "b"}},
+		{name: "empty", documents: `[]`, error: true},
+		{name: "object without text", documents: `[{"title": "a"}]`, error: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw []json.RawMessage
+			if err := json.Unmarshal([]byte(tt.documents), &raw); err != nil {
+				t.Fatal(err)
+			}
+			documents, err := parseDocuments(raw)
+			if tt.error {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", documents)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(documents, tt.expected) {
+				t.Errorf("Expected %q, got %q (%v)", tt.expected, documents, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package rerank
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"llm-mock-server/pkg/tokenizer"
+)
+
+// maxDocuments is the maximum number of documents of a request.
+const maxDocuments = 1000
+
+// rerankRequest is the request of Cohere and Jina, which DashScope nests in input and parameters.
+type rerankRequest struct {
+	Model string `json:"model"`
+	Query string `json:"query"`
+	// Documents are strings, or objects with a text field.
+	Documents       []json.RawMessage `json:"documents"`
+	TopN            *int              `json:"top_n,omitempty"`
+	ReturnDocuments *bool             `json:"return_documents,omitempty"`
+}
+
+type rerankResult struct {
+	Index          int             `json:"index"`
+	RelevanceScore float64         `json:"relevance_score"`
+	Document       *rerankDocument `json:"document,omitempty"`
+}
+
+type rerankDocument struct {
+	Text string `json:"text"`
+}
+
+// rerank returns the documents by decreasing relevance to the query, the first top_n of them, with
+// their text if the documents are returned.
+func rerank(query string, documents []string, topN *int, returnDocuments bool) ([]rerankResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query must not be empty")
+	}
+	n := len(documents)
+	if topN != nil {
+		if *topN < 1 {
+			return nil, fmt.Errorf("top_n must be at least 1")
+		}
+		if *topN < n {
+			n = *topN
+		}
+	}
+
+	results := make([]rerankResult, 0, len(documents))
+	queryWords := wordSet(query)
+	for i, document := range documents {
+		result := rerankResult{Index: i, RelevanceScore: score(queryWords, document)}
+		if returnDocuments {
+			result.Document = &rerankDocument{Text: document}
+		}
+		results = append(results, result)
+	}
+	// Equally relevant documents keep their order.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	return results[:n], nil
+}
+
+// score returns the relevance of the document to the query words, between 0 and 1: mostly the
+// share of the query words the document contains, then the share of the document they make up, so
+// that a short document matching the query wins over a long one that mentions it in passing.
+func score(queryWords map[string]bool, document string) float64 {
+	words := tokenizer.Words(document)
+	if len(queryWords) == 0 || len(words) == 0 {
+		return 0
+	}
+	matched := make(map[string]bool)
+	occurrences := 0
+	for _, word := range words {
+		if queryWords[word] {
+			matched[word] = true
+			occurrences++
+		}
+	}
+	coverage := float64(len(matched)) / float64(len(queryWords))
+	density := float64(occurrences) / float64(len(words))
+	// Round like the real APIs, which return a handful of significant digits.
+	return math.Round((0.8*coverage+0.2*density)*1e6) / 1e6
+}
+
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range tokenizer.Words(text) {
+		words[word] = true
+	}
+	return words
+}
+
+// parseDocuments returns the texts of the documents, which are strings or objects with a text field.
+func parseDocuments(raw []json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("documents must not be empty")
+	}
+	if len(raw) > maxDocuments {
+		return nil, fmt.Errorf("documents must have at most %d items", maxDocuments)
+	}
+	documents := make([]string, 0, len(raw))
+	for i, document := range raw {
+		var text string
+		if err := json.Unmarshal(document, &text); err != nil {
+			var object rerankDocument
+			if err := json.Unmarshal(document, &object); err != nil || object.Text == "" {
+				return nil, fmt.Errorf("documents[%d] must be a string or an object with a text field", i)
+			}
+			text = object.Text
+		}
+		documents = append(documents, text)
+	}
+	return documents, nil
+}
+
+// countTokens returns the tokens of the query and the documents, which Jina and DashScope bill.
+func countTokens(model, query string, documents []string) int {
+	counter := tokenizer.ForModel(model)
+	tokens := counter.Count(query)
+	for _, document := range documents {
+		tokens += counter.Count(document)
+	}
+	return tokens
+}
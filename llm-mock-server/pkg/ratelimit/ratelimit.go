@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"llm-mock-server/pkg/config"
+)
+
+// Exceeded budgets reported by Status.Exceeded.
+const (
+	Requests = "requests"
+	Tokens   = "tokens"
+)
+
+// Status is the state of the budgets of a key after a request was admitted or rejected.
+type Status struct {
+	RequestLimit      int
+	RequestsRemaining int
+	// RequestsReset is the time until the request budget is full again.
+	RequestsReset   time.Duration
+	TokenLimit      int
+	TokensRemaining int
+	// TokensReset is the time until the token budget is full again.
+	TokensReset time.Duration
+	// Exceeded names the exhausted budget (Requests or Tokens), or is empty if the request was admitted.
+	Exceeded string
+	// RetryAfter is the time until the exhausted budget admits a request again.
+	RetryAfter time.Duration
+}
+
+// bucket is a token bucket holding up to capacity units and refilled at capacity units per minute.
+// Its level may go negative when a request costs more tokens than were left.
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func newBucket(capacity int, now time.Time) *bucket {
+	return &bucket{capacity: float64(capacity), level: float64(capacity), updated: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.level = math.Min(b.capacity, b.level+now.Sub(b.updated).Minutes()*b.capacity)
+	b.updated = now
+}
+
+// until returns the time until the bucket holds the given level.
+func (b *bucket) until(level float64) time.Duration {
+	if b.level >= level {
+		return 0
+	}
+	return time.Duration((level - b.level) / b.capacity * float64(time.Minute))
+}
+
+type buckets struct {
+	limits   config.RateLimitConfig
+	requests *bucket
+	tokens   *bucket
+}
+
+// Limiter keeps a request and a token bucket per key. Keys are built by the caller, typically from
+// the provider, the API key and the model.
+type Limiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*buckets
+	now     func() time.Time
+}
+
+func New() *Limiter {
+	return &Limiter{buckets: make(map[string]*buckets), now: time.Now}
+}
+
+// DefaultLimiter is the limiter shared by the provider mocks.
+var DefaultLimiter = New()
+
+// Acquire takes one request from the budgets of the key if both the request and the token budget
+// allow it. A nil or zero limit never rejects.
+func (l *Limiter) Acquire(key string, limits *config.RateLimitConfig) Status {
+	if limits == nil || *limits == (config.RateLimitConfig{}) {
+		return Status{}
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	b := l.get(key, *limits)
+
+	status := Status{}
+	switch {
+	case b.requests != nil && b.requests.level < 1:
+		status.Exceeded, status.RetryAfter = Requests, b.requests.until(1)
+	case b.tokens != nil && b.tokens.level <= 0:
+		status.Exceeded, status.RetryAfter = Tokens, b.tokens.until(1)
+	case b.requests != nil:
+		b.requests.level--
+	}
+	b.fill(&status)
+	return status
+}
+
+// Charge takes the tokens used by an admitted request from the token budget of the key.
+func (l *Limiter) Charge(key string, limits *config.RateLimitConfig, tokens int) {
+	if limits == nil || limits.TokensPerMinute == 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if b := l.get(key, *limits); b.tokens != nil {
+		b.tokens.level -= float64(tokens)
+	}
+}
+
+// Reset forgets every bucket, giving every key its full budgets back.
+func (l *Limiter) Reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.buckets = make(map[string]*buckets)
+}
+
+// get returns the refilled buckets of the key, creating them (again if the limits changed).
+func (l *Limiter) get(key string, limits config.RateLimitConfig) *buckets {
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok || b.limits != limits {
+		b = &buckets{limits: limits}
+		if limits.RequestsPerMinute > 0 {
+			b.requests = newBucket(limits.RequestsPerMinute, now)
+		}
+		if limits.TokensPerMinute > 0 {
+			b.tokens = newBucket(limits.TokensPerMinute, now)
+		}
+		l.buckets[key] = b
+	}
+	for _, budget := range []*bucket{b.requests, b.tokens} {
+		if budget != nil {
+			budget.refill(now)
+		}
+	}
+	return b
+}
+
+func (b *buckets) fill(status *Status) {
+	if b.requests != nil {
+		status.RequestLimit = b.limits.RequestsPerMinute
+		status.RequestsRemaining = int(math.Max(0, math.Floor(b.requests.level)))
+		status.RequestsReset = b.requests.until(b.requests.capacity)
+	}
+	if b.tokens != nil {
+		status.TokenLimit = b.limits.TokensPerMinute
+		status.TokensRemaining = int(math.Max(0, math.Floor(b.tokens.level)))
+		status.TokensReset = b.tokens.until(b.tokens.capacity)
+	}
+}
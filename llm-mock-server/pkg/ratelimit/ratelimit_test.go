@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"llm-mock-server/pkg/config"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New()
+	l.now = func() time.Time { return now }
+	limits := &config.RateLimitConfig{RequestsPerMinute: 2, TokensPerMinute: 100}
+
+	for i := 0; i < 2; i++ {
+		if status := l.Acquire("k", limits); status.Exceeded != "" {
+			t.Fatalf("Expected request %d to be admitted, got %+v", i, status)
+		}
+	}
+	status := l.Acquire("k", limits)
+	if status.Exceeded != Requests || status.RetryAfter != 30*time.Second {
+		t.Errorf("Expected the request budget to be exhausted for 30s, got %+v", status)
+	}
+	if other := l.Acquire("other", limits); other.Exceeded != "" || other.RequestsRemaining != 1 {
+		t.Errorf("Expected another key to have its own budget, got %+v", other)
+	}
+
+	now = now.Add(30 * time.Second)
+	l.Charge("k", limits, 150)
+	status = l.Acquire("k", limits)
+	if status.Exceeded != Tokens || status.TokensRemaining != 0 || status.RetryAfter != 30600*time.Millisecond {
+		t.Errorf("Expected the token budget to be exhausted, got %+v", status)
+	}
+
+	l.Reset()
+	if status := l.Acquire("k", limits); status.Exceeded != "" || status.RequestsRemaining != 1 || status.TokensRemaining != 100 {
+		t.Errorf("Expected the budgets to be full after a reset, got %+v", status)
+	}
+}
@@ -0,0 +1,211 @@
+package schema
+
+import "strings"
+
+// maxDepth bounds the nesting of the generated values, so that recursive schemas terminate.
+const maxDepth = 8
+
+// Example returns a value valid against the JSON schema, such as the arguments of a tool call
+// generated from the parameters of the tool. It honors the type, the enum, const, default and
+// examples keywords, the required properties, the minimum of numbers, the length and the format of
+// strings, the minItems of arrays, anyOf/oneOf/allOf and the local $ref. The value only depends on
+// the schema, so that the same tool is always called with the same arguments.
+func Example(schema map[string]interface{}) interface{} {
+	return (&generator{root: schema}).value(schema, "", 0)
+}
+
+type generator struct {
+	root map[string]interface{}
+}
+
+func (g *generator) value(schema map[string]interface{}, name string, depth int) interface{} {
+	if schema == nil || depth > maxDepth {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return g.value(g.resolve(ref), name, depth+1)
+	}
+	if value, ok := schema["const"]; ok {
+		return value
+	}
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	if value, ok := schema["default"]; ok {
+		return value
+	}
+	if values, ok := schema["examples"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[keyword].([]interface{}); ok {
+			// Prefer the first option that is not null.
+			for _, option := range options {
+				if option, ok := option.(map[string]interface{}); ok && option["type"] != "null" {
+					return g.value(option, name, depth+1)
+				}
+			}
+		}
+	}
+	if parts, ok := schema["allOf"].([]interface{}); ok {
+		return g.value(merge(schema, parts), name, depth+1)
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return g.object(schema, depth)
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		count := int(number(schema, "minItems", 1))
+		values := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			values = append(values, g.value(items, name, depth+1))
+		}
+		return values
+	case "integer":
+		return int64(number(schema, "minimum", number(schema, "exclusiveMinimum", 0)+1))
+	case "number":
+		return number(schema, "minimum", number(schema, "exclusiveMinimum", 0)+1) + 0.5
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	}
+	return str(schema, name)
+}
+
+func (g *generator) object(schema map[string]interface{}, depth int) map[string]interface{} {
+	object := map[string]interface{}{}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		property, _ := property.(map[string]interface{})
+		object[name] = g.value(property, name, depth+1)
+	}
+	// Required properties without a schema still get a value.
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := object[name]; !ok {
+					object[name] = str(nil, name)
+				}
+			}
+		}
+	}
+	return object
+}
+
+// resolve returns the schema of a local reference such as "#/$defs/address", or nil.
+func (g *generator) resolve(ref string) map[string]interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	node := g.root
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		next, ok := node[segment].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// schemaType returns the type of the schema, the first non-null one of a type list, or the type
+// implied by the keywords when none is given.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		// Gemini declares the OpenAPI types in upper case.
+		return strings.ToLower(t)
+	case []interface{}:
+		for _, t := range t {
+			if t, ok := t.(string); ok && t != "null" {
+				return strings.ToLower(t)
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return "string"
+}
+
+// str returns a string named after the property, in the format of the schema if it has one.
+func str(schema map[string]interface{}, name string) string {
+	format, _ := schema["format"].(string)
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "mock@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000000"
+	case "ipv4":
+		return "127.0.0.1"
+	}
+	value := "mock"
+	if name != "" {
+		value = "mock " + name
+	}
+	if min := int(number(schema, "minLength", 0)); len(value) < min {
+		value += strings.Repeat("x", min-len(value))
+	}
+	if max := int(number(schema, "maxLength", 0)); max > 0 && len(value) > max {
+		value = value[:max]
+	}
+	return value
+}
+
+// number returns the numeric keyword of the schema, or the fallback if it has none.
+func number(schema map[string]interface{}, keyword string, fallback float64) float64 {
+	if value, ok := schema[keyword].(float64); ok {
+		return value
+	}
+	return fallback
+}
+
+// merge returns the schema with the properties and required lists of its allOf parts merged in.
+func merge(schema map[string]interface{}, parts []interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	properties := map[string]interface{}{}
+	var required []interface{}
+	for _, part := range append([]interface{}{schema}, parts...) {
+		part, ok := part.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range part {
+			if key != "allOf" {
+				merged[key] = value
+			}
+		}
+		if partProperties, ok := part["properties"].(map[string]interface{}); ok {
+			for name, property := range partProperties {
+				properties[name] = property
+			}
+		}
+		if partRequired, ok := part["required"].([]interface{}); ok {
+			required = append(required, partRequired...)
+		}
+	}
+	if len(properties) > 0 {
+		merged["properties"] = properties
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged
+}
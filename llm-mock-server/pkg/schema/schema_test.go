@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExample(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{
+			name: "weather",
+			schema: `{"type": "object", "properties": {
+				"location": {"type": "string"},
+				"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+				"days": {"type": "integer", "minimum": 3}
+			}, "required": ["location"]}`,
+			expected: `{"location": "mock location", "unit": "celsius", "days": 3}`,
+		},
+		{
+			name: "nested",
+			schema: `{"type": "object", "properties": {
+				"tags": {"type": "array", "items": {"type": "string", "format": "email"}, "minItems": 2},
+				"when": {"type": ["string", "null"], "format": "date"},
+				"score": {"type": "number"},
+				"ok": {"type": "boolean"}
+			}}`,
+			expected: `{"tags": ["mock@example.com", "mock@example.com"], "when": "2024-01-01", "score": 1.5, "ok": true}`,
+		},
+		{
+			name: "refs",
+			schema: `{"type": "object", "properties": {"address": {"$ref": "#/$defs/address"}},
+				"$defs": {"address": {"type": "object", "properties": {"city": {"anyOf": [{"type": "null"}, {"const": "Paris"}]}}}}}`,
+			expected: `{"address": {"city": "Paris"}}`,
+		},
+		{
+			name:     "gemini",
+			schema:   `{"type": "OBJECT", "properties": {"n": {"type": "INTEGER"}}, "required": ["n", "q"]}`,
+			expected: `{"n": 1, "q": "mock q"}`,
+		},
+		{
+			name:     "recursive",
+			schema:   `{"$ref": "#"}`,
+			expected: `null`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var schema map[string]interface{}
+			if err := json.Unmarshal([]byte(test.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			// Compare through JSON, which is what the arguments are sent as.
+			got, _ := json.Marshal(Example(schema))
+			var gotValue, expected interface{}
+			json.Unmarshal(got, &gotValue)
+			json.Unmarshal([]byte(test.expected), &expected)
+			if !reflect.DeepEqual(gotValue, expected) {
+				t.Errorf("Expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package stub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-mock-server/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stub is a response definition registered at runtime through the admin API. A stub whose
+// matcher accepts a chat request is served instead of the built-in provider mock.
+type Stub struct {
+	Id       string   `json:"id"`
+	Request  Matcher  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Matcher selects the requests a stub applies to. Empty fields match anything.
+type Matcher struct {
+	Provider string `json:"provider,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// PromptRegex is matched against the text of the last user message.
+	PromptRegex string `json:"promptRegex,omitempty"`
+	// Headers must all be present on the request with exactly the given values.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	promptRegex *regexp.Regexp
+}
+
+// Response describes what a stub writes back.
+type Response struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is written as-is when it is a JSON string, and as a JSON document otherwise.
+	Body json.RawMessage `json:"body,omitempty"`
+	// Chunks turns the response into a stream: every chunk is written verbatim and flushed.
+	Chunks     []string        `json:"chunks,omitempty"`
+	ChunkDelay config.Duration `json:"chunkDelay,omitempty"`
+	// Delay is waited before the response is written.
+	Delay config.Duration `json:"delay,omitempty"`
+}
+
+// Request is the view of a chat request that matchers are evaluated against.
+type Request struct {
+	Provider string
+	Path     string
+	Model    string
+	Prompt   string
+	Header   http.Header
+}
+
+func (m *Matcher) compile() error {
+	if m.PromptRegex == "" {
+		return nil
+	}
+	re, err := regexp.Compile(m.PromptRegex)
+	if err != nil {
+		return fmt.Errorf("invalid promptRegex: %v", err)
+	}
+	m.promptRegex = re
+	return nil
+}
+
+// Matches reports whether the matcher accepts the request.
+func (m *Matcher) Matches(req Request) bool {
+	if m.Provider != "" && !strings.EqualFold(m.Provider, req.Provider) {
+		return false
+	}
+	if m.Path != "" && m.Path != req.Path {
+		return false
+	}
+	if m.Model != "" && m.Model != req.Model {
+		return false
+	}
+	if m.promptRegex != nil && !m.promptRegex.MatchString(req.Prompt) {
+		return false
+	}
+	for name, value := range m.Headers {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Store keeps the registered stubs. The most recently added stub wins when several match.
+type Store struct {
+	mutex sync.RWMutex
+	stubs []*Stub
+}
+
+// DefaultStore is the store shared by the admin API and the chat handlers.
+var DefaultStore = &Store{}
+
+// Add validates the stub, assigns it an id and registers it.
+func (s *Store) Add(stub *Stub) error {
+	if err := stub.Request.compile(); err != nil {
+		return err
+	}
+	if stub.Response.Status != 0 && (stub.Response.Status < 100 || stub.Response.Status > 599) {
+		return fmt.Errorf("invalid response status: %d", stub.Response.Status)
+	}
+	if stub.Id == "" {
+		stub.Id = newId()
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// A stub added again under its id replaces the old one and becomes the most recent.
+	for i, existing := range s.stubs {
+		if existing.Id == stub.Id {
+			s.stubs = append(s.stubs[:i], s.stubs[i+1:]...)
+			break
+		}
+	}
+	s.stubs = append(s.stubs, stub)
+	return nil
+}
+
+func (s *Store) List() []*Stub {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]*Stub{}, s.stubs...)
+}
+
+func (s *Store) Get(id string) *Stub {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, stub := range s.stubs {
+		if stub.Id == id {
+			return stub
+		}
+	}
+	return nil
+}
+
+// Delete removes the stub with the given id and reports whether it existed.
+func (s *Store) Delete(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, stub := range s.stubs {
+		if stub.Id == id {
+			s.stubs = append(s.stubs[:i], s.stubs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reset removes all stubs.
+func (s *Store) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stubs = nil
+}
+
+// Match returns the newest stub accepting the request, or nil if there is none.
+func (s *Store) Match(req Request) *Stub {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for i := len(s.stubs) - 1; i >= 0; i-- {
+		if s.stubs[i].Request.Matches(req) {
+			return s.stubs[i]
+		}
+	}
+	return nil
+}
+
+// Serve writes the stub response.
+func Serve(ctx *gin.Context, stub *Stub) {
+	response := stub.Response
+	if !sleep(ctx, response.Delay.Duration()) {
+		return
+	}
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	for name, value := range response.Headers {
+		ctx.Header(name, value)
+	}
+
+	if len(response.Chunks) > 0 {
+		if ctx.Writer.Header().Get("Content-Type") == "" {
+			ctx.Header("Content-Type", "text/event-stream")
+		}
+		ctx.Status(status)
+		for i, chunk := range response.Chunks {
+			if i > 0 && !sleep(ctx, response.ChunkDelay.Duration()) {
+				return
+			}
+			ctx.Writer.Write([]byte(chunk))
+			ctx.Writer.Flush()
+		}
+		return
+	}
+
+	var text string
+	if err := json.Unmarshal(response.Body, &text); err == nil {
+		contentType := ctx.Writer.Header().Get("Content-Type")
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		ctx.Data(status, contentType, []byte(text))
+		return
+	}
+	if len(response.Body) == 0 {
+		ctx.Status(status)
+		return
+	}
+	ctx.Data(status, "application/json", response.Body)
+}
+
+func sleep(ctx *gin.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Request.Context().Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+func newId() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
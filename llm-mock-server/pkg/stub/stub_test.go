@@ -0,0 +1,92 @@
+package stub
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestStoreMatch(t *testing.T) {
+	store := &Store{}
+	stubs := []*Stub{
+		{Id: "any"},
+		{Id: "claude", Request: Matcher{Provider: "claude", PromptRegex: "^weather"}},
+		{Id: "header", Request: Matcher{Path: "/v1/chat/completions", Headers: map[string]string{"X-Case": "retry"}}},
+	}
+	for _, s := range stubs {
+		if err := store.Add(s); err != nil {
+			t.Fatalf("Failed to add stub %s: %v", s.Id, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		request  Request
+		expected string
+	}{
+		{
+			name:     "provider and prompt",
+			request:  Request{Provider: "Claude", Prompt: "weather in Beijing"},
+			expected: "claude",
+		},
+		{
+			name:     "prompt mismatch falls back to older stub",
+			request:  Request{Provider: "claude", Prompt: "hello"},
+			expected: "any",
+		},
+		{
+			name:     "header",
+			request:  Request{Path: "/v1/chat/completions", Header: http.Header{"X-Case": []string{"retry"}}},
+			expected: "header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := store.Match(tt.request)
+			if matched == nil || matched.Id != tt.expected {
+				t.Errorf("Expected stub %s, got %+v", tt.expected, matched)
+			}
+		})
+	}
+
+	store.Delete("any")
+	if matched := store.Match(Request{Provider: "openai"}); matched != nil {
+		t.Errorf("Expected no stub to match, got %s", matched.Id)
+	}
+}
+
+func TestStoreReAdd(t *testing.T) {
+	store := &Store{}
+	for _, s := range []*Stub{
+		{Id: "weather", Request: Matcher{PromptRegex: "weather"}},
+		{Id: "any"},
+	} {
+		if err := store.Add(s); err != nil {
+			t.Fatalf("Failed to add stub %s: %v", s.Id, err)
+		}
+	}
+	if matched := store.Match(Request{Prompt: "weather"}); matched == nil || matched.Id != "any" {
+		t.Fatalf("Expected the newer stub to win, got %+v", matched)
+	}
+
+	// Registering an id again makes the stub the most recent one.
+	if err := store.Add(&Stub{Id: "weather", Request: Matcher{PromptRegex: "^weather"}}); err != nil {
+		t.Fatalf("Failed to re-add stub: %v", err)
+	}
+	if matched := store.Match(Request{Prompt: "weather"}); matched == nil || matched.Id != "weather" || matched.Request.PromptRegex != "^weather" {
+		t.Errorf("Expected the re-added stub to win, got %+v", matched)
+	}
+	if got := len(store.List()); got != 2 {
+		t.Errorf("Expected the re-added stub to replace the old one, got %d stubs", got)
+	}
+}
+
+func TestStoreAddInvalid(t *testing.T) {
+	store := &Store{}
+	if err := store.Add(&Stub{Request: Matcher{PromptRegex: "("}}); err == nil {
+		t.Error("Expected error for invalid promptRegex, but got none")
+	}
+	if err := store.Add(&Stub{Response: Response{Status: 1000}}); err == nil {
+		t.Error("Expected error for invalid status, but got none")
+	}
+}
@@ -0,0 +1,194 @@
+package tokenizer
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Names of the built-in tokenizers.
+const (
+	CL100K = "cl100k"
+	O200K  = "o200k"
+	Claude = "claude"
+	Gemini = "gemini"
+	Qwen   = "qwen"
+)
+
+// Tokenizer counts the tokens a model family would bill for a text. The built-in tokenizers are
+// approximations: they do not ship the vocabularies, but follow the way each family splits words,
+// digits and CJK text closely enough for usage-based plugins to be tested meaningfully.
+type Tokenizer interface {
+	// Count returns the number of tokens of the text.
+	Count(text string) int
+	// Truncate returns the longest prefix of the text that fits in the given number of tokens.
+	Truncate(text string, tokens int) string
+	// CountMessages returns the number of prompt tokens of a conversation, including the tokens
+	// the chat template adds around every message and before the reply.
+	CountMessages(messages []string) int
+}
+
+// approximation estimates the tokens of a text from its pre-tokenized pieces, the way BPE and
+// SentencePiece tokenizers first split a text into words, numbers and punctuation.
+type approximation struct {
+	// wordChars is the number of letters per token of an ASCII word. Non-ASCII words use half of it.
+	wordChars float64
+	// digitGroup is the number of digits per token: 3 for the OpenAI encodings, 1 for the
+	// tokenizers that split numbers into single digits.
+	digitGroup int
+	// cjkTokens is the number of tokens per CJK character.
+	cjkTokens float64
+	// messageTokens is the number of template tokens around every message, role included.
+	messageTokens int
+	// replyTokens is the number of template tokens priming the reply.
+	replyTokens int
+}
+
+var (
+	mutex      sync.RWMutex
+	tokenizers = map[string]Tokenizer{
+		CL100K: &approximation{wordChars: 6, digitGroup: 3, cjkTokens: 1.1, messageTokens: 4, replyTokens: 3},
+		O200K:  &approximation{wordChars: 6.5, digitGroup: 3, cjkTokens: 0.8, messageTokens: 4, replyTokens: 3},
+		Claude: &approximation{wordChars: 5, digitGroup: 3, cjkTokens: 1.3, messageTokens: 4, replyTokens: 4},
+		Gemini: &approximation{wordChars: 6, digitGroup: 1, cjkTokens: 0.8},
+		Qwen:   &approximation{wordChars: 6, digitGroup: 1, cjkTokens: 0.7, messageTokens: 5, replyTokens: 3},
+	}
+)
+
+// Register adds a tokenizer, or replaces the one with the same name.
+func Register(name string, tokenizer Tokenizer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	tokenizers[name] = tokenizer
+}
+
+// Get returns the named tokenizer, or nil if there is none.
+func Get(name string) Tokenizer {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return tokenizers[name]
+}
+
+// Names returns the names of the registered tokenizers.
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForModel returns the tokenizer of the model family, defaulting to cl100k.
+func ForModel(model string) Tokenizer {
+	model = strings.ToLower(model)
+	switch {
+	case strings.Contains(model, "claude"):
+		return Get(Claude)
+	case strings.Contains(model, "gemini") || strings.Contains(model, "gemma"):
+		return Get(Gemini)
+	case strings.Contains(model, "qwen") || strings.Contains(model, "qwq"):
+		return Get(Qwen)
+	case strings.HasPrefix(model, "gpt-4o") || strings.HasPrefix(model, "gpt-4.1") || strings.HasPrefix(model, "gpt-5") ||
+		strings.HasPrefix(model, "o1") || strings.HasPrefix(model, "o3") || strings.HasPrefix(model, "o4") ||
+		strings.HasPrefix(model, "chatgpt-4o"):
+		return Get(O200K)
+	}
+	return Get(CL100K)
+}
+
+// Select returns the named tokenizer if there is one, otherwise the tokenizer of the model family.
+func Select(name, model string) Tokenizer {
+	if tokenizer := Get(name); tokenizer != nil {
+		return tokenizer
+	}
+	return ForModel(model)
+}
+
+func (a *approximation) CountMessages(messages []string) int {
+	if len(messages) == 0 {
+		return 0
+	}
+	count := a.replyTokens
+	for _, message := range messages {
+		count += a.messageTokens + a.Count(message)
+	}
+	return count
+}
+
+func (a *approximation) Count(text string) int {
+	count := 0.0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		end, tokens := a.piece(runes, i)
+		count += tokens
+		i = end
+	}
+	return int(count)
+}
+
+func (a *approximation) Truncate(text string, tokens int) string {
+	count := 0.0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		end, cost := a.piece(runes, i)
+		if count+cost > float64(tokens) {
+			// Keep the part of the piece that fits, e.g. the first tokens of a long word.
+			keep := int(float64(end-i) * (float64(tokens) - count) / cost)
+			return strings.TrimRight(string(runes[:i+keep]), " ")
+		}
+		count += cost
+		i = end
+	}
+	return text
+}
+
+// piece returns the end and the number of tokens of the pre-tokenized piece starting at i.
+func (a *approximation) piece(runes []rune, i int) (int, float64) {
+	r := runes[i]
+	j := i + 1
+	switch {
+	case isCJK(r):
+		for j < len(runes) && isCJK(runes[j]) {
+			j++
+		}
+		return j, math.Ceil(float64(j-i) * a.cjkTokens)
+	case unicode.IsLetter(r):
+		ascii := r < unicode.MaxASCII
+		for j < len(runes) && unicode.IsLetter(runes[j]) && !isCJK(runes[j]) {
+			ascii = ascii && runes[j] < unicode.MaxASCII
+			j++
+		}
+		chars := a.wordChars
+		if !ascii {
+			chars /= 2
+		}
+		return j, math.Ceil(float64(j-i) / chars)
+	case unicode.IsDigit(r):
+		for j < len(runes) && unicode.IsDigit(runes[j]) {
+			j++
+		}
+		return j, math.Ceil(float64(j-i) / float64(a.digitGroup))
+	case unicode.IsSpace(r):
+		for j < len(runes) && unicode.IsSpace(runes[j]) {
+			j++
+		}
+		// A single space is merged into the following word, like " the" in BPE vocabularies.
+		if j-i > 1 || r != ' ' || j == len(runes) {
+			return j, 1
+		}
+		return j, 0
+	case r < unicode.MaxASCII:
+		return j, 1
+	}
+	// Emoji and other symbols are split into several byte-level tokens.
+	return j, 2
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,94 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		tokenizer string
+		text      string
+		expected  int
+	}{
+		{CL100K, "", 0},
+		{CL100K, "Hello, world!", 4},
+		{CL100K, "internationalization", 4},
+		{CL100K, "1234567", 3},
+		{Gemini, "1234567", 7},
+		{CL100K, "你好世界", 5},
+		{O200K, "你好世界", 4},
+		{Qwen, "你好世界", 3},
+		{CL100K, "line one\n\nline two", 5},
+	}
+	for _, test := range tests {
+		if got := Get(test.tokenizer).Count(test.text); got != test.expected {
+			t.Errorf("%s.Count(%q) = %d, expected %d", test.tokenizer, test.text, got, test.expected)
+		}
+	}
+}
+
+func TestCountMessages(t *testing.T) {
+	if got := Get(CL100K).CountMessages([]string{"Hello, world!"}); got != 11 {
+		t.Errorf("Expected the chat template tokens to be counted, got %d", got)
+	}
+	if got := Get(Gemini).CountMessages([]string{"Hello, world!"}); got != 4 {
+		t.Errorf("Expected Gemini to count the text only, got %d", got)
+	}
+	if got := Get(CL100K).CountMessages(nil); got != 0 {
+		t.Errorf("Expected an empty conversation to have no tokens, got %d", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name, model string
+		expected    string
+	}{
+		{"", "gpt-4o-mini", O200K},
+		{"", "gpt-3.5-turbo", CL100K},
+		{"", "claude-3-5-sonnet-20241022", Claude},
+		{"", "anthropic.claude-3-haiku-20240307-v1:0", Claude},
+		{"", "gemini-2.0-flash", Gemini},
+		{"", "qwen-max", Qwen},
+		{"", "unknown", CL100K},
+		{Qwen, "gpt-4o", Qwen},
+	}
+	for _, test := range tests {
+		if got := Select(test.name, test.model); got != Get(test.expected) {
+			t.Errorf("Select(%q, %q) did not return the %s tokenizer", test.name, test.model, test.expected)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text     string
+		tokens   int
+		expected string
+	}{
+		{"Hello, world!", 10, "Hello, world!"},
+		{"Hello, world!", 3, "Hello, world"},
+		{"Hello, world!", 2, "Hello,"},
+		{"internationalization", 2, "internatio"},
+		{"你好世界", 3, "你好"},
+		{"Hello", 0, ""},
+	}
+	cl100k := Get(CL100K)
+	for _, test := range tests {
+		got := cl100k.Truncate(test.text, test.tokens)
+		if got != test.expected {
+			t.Errorf("Truncate(%q, %d) = %q, expected %q", test.text, test.tokens, got, test.expected)
+		}
+		if count := cl100k.Count(got); count > test.tokens {
+			t.Errorf("Truncate(%q, %d) returned %d tokens", test.text, test.tokens, count)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	expected := []string{"reset", "my", "password2", "限", "流"}
+	if got := Words("Reset my PASSWORD2, 限流!"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
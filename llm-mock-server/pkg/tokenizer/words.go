@@ -0,0 +1,33 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words splits the lower-cased text into words of letters and digits, for the mocks that compare
+// texts. Every Chinese, Japanese or Korean character is a word of its own, since these scripts do
+// not separate words.
+func Words(text string) []string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
@@ -1,6 +1,14 @@
 package utils
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeaders are the headers the providers carry their API key in, besides Authorization.
+var apiKeyHeaders = []string{"x-api-key", "x-goog-api-key", "api-key"}
 
 func SetEventStreamHeaders(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -9,3 +17,26 @@ func SetEventStreamHeaders(c *gin.Context) {
 	c.Writer.Header().Set("Transfer-Encoding", "chunked")
 	c.Writer.Header().Set("X-Accel-Buffering", "no")
 }
+
+// APIKey returns the API key or access key id the request is authenticated with, whatever the
+// provider: a bearer token, a provider-specific key header, the "key" query parameter, or the
+// Credential of an AWS SigV4 / Tencent TC3 signature. It returns "" for anonymous requests.
+func APIKey(request *http.Request) string {
+	authorization := request.Header.Get("Authorization")
+	if token, found := strings.CutPrefix(authorization, "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+	if _, credential, found := strings.Cut(authorization, "Credential="); found {
+		id, _, _ := strings.Cut(credential, "/")
+		return id
+	}
+	for _, header := range apiKeyHeaders {
+		if key := request.Header.Get(header); key != "" {
+			return key
+		}
+	}
+	if key := request.URL.Query().Get("key"); key != "" {
+		return key
+	}
+	return strings.TrimSpace(authorization)
+}